@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+type ApplyStatus string
+
+const (
+	ApplyStatusPending    ApplyStatus = "pending"
+	ApplyStatusInProgress ApplyStatus = "in-progress"
+	ApplyStatusDone       ApplyStatus = "done"
+	ApplyStatusFailed     ApplyStatus = "failed"
+)
+
+// ApplyEvent is the apply state of a single resource, ID matches the graph NodeData ID
+type ApplyEvent struct {
+	ID      string      `json:"id"`
+	Status  ApplyStatus `json:"status"`
+	Action  string      `json:"action,omitempty"`
+	Elapsed float64     `json:"elapsed,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+// ApplyOverview is the current state of an apply
+type ApplyOverview struct {
+	Resources   map[string]*ApplyEvent `json:"resources"`
+	Done        bool                   `json:"done"`
+	Summary     *ChangeSummary         `json:"summary,omitempty"`
+	Diagnostics []Diagnostic           `json:"diagnostics,omitempty"`
+}
+
+// ApplyWatcher tracks apply -json events and broadcasts them to subscribers
+type ApplyWatcher struct {
+	mu          sync.Mutex
+	overview    ApplyOverview
+	subscribers map[chan []byte]bool
+}
+
+// NewApplyWatcher creates a watcher with every resource the plan changes marked as pending
+func NewApplyWatcher(plan *tfjson.Plan) *ApplyWatcher {
+	aw := &ApplyWatcher{
+		overview: ApplyOverview{
+			Resources: map[string]*ApplyEvent{},
+		},
+		subscribers: map[chan []byte]bool{},
+	}
+
+	if plan != nil {
+		for _, rc := range plan.ResourceChanges {
+			if rc.Change == nil || rc.Change.Actions.NoOp() {
+				continue
+			}
+			aw.overview.Resources[rc.Address] = &ApplyEvent{
+				ID:     rc.Address,
+				Status: ApplyStatusPending,
+			}
+		}
+	}
+
+	return aw
+}
+
+// Overview returns a copy of the current apply state
+func (aw *ApplyWatcher) Overview() ApplyOverview {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	o := aw.overview
+	o.Resources = make(map[string]*ApplyEvent, len(aw.overview.Resources))
+	for id, e := range aw.overview.Resources {
+		ev := *e
+		o.Resources[id] = &ev
+	}
+	o.Diagnostics = append([]Diagnostic{}, aw.overview.Diagnostics...)
+
+	return o
+}
+
+// Subscribe returns a channel that receives server-sent events, starting with the current state
+func (aw *ApplyWatcher) Subscribe() chan []byte {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	ch := make(chan []byte, len(aw.overview.Resources)+16)
+	for _, e := range aw.overview.Resources {
+		ch <- sseMessage("resource", e)
+	}
+	if aw.overview.Done {
+		ch <- sseMessage("done", aw.overview.Summary)
+	}
+	aw.subscribers[ch] = true
+
+	return ch
+}
+
+// Unsubscribe stops sending events to ch
+func (aw *ApplyWatcher) Unsubscribe(ch chan []byte) {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	delete(aw.subscribers, ch)
+}
+
+// broadcast sends msg to every subscriber. Subscribers whose buffer is full are dropped
+// and their channel closed, so the browser reconnects and gets the current state again.
+// Must hold aw.mu
+func (aw *ApplyWatcher) broadcast(msg []byte) {
+	for ch := range aw.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			log.Println("Dropping slow apply subscriber...")
+			delete(aw.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Handle updates the apply state with a single apply -json message
+func (aw *ApplyWatcher) Handle(msg *planLogMessage) {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	switch msg.Type {
+	case "apply_start", "apply_progress", "apply_complete", "apply_errored":
+		if msg.Hook == nil {
+			return
+		}
+
+		id := msg.Hook.Resource.Addr
+		if _, ok := aw.overview.Resources[id]; !ok {
+			aw.overview.Resources[id] = &ApplyEvent{ID: id}
+		}
+
+		e := aw.overview.Resources[id]
+		e.Action = msg.Hook.Action
+		e.Elapsed = msg.Hook.ElapsedSeconds
+
+		switch msg.Type {
+		case "apply_start", "apply_progress":
+			e.Status = ApplyStatusInProgress
+		case "apply_complete":
+			e.Status = ApplyStatusDone
+		case "apply_errored":
+			e.Status = ApplyStatusFailed
+		}
+
+		aw.broadcast(sseMessage("resource", e))
+	case "diagnostic":
+		if msg.Diagnostic == nil {
+			return
+		}
+
+		aw.overview.Diagnostics = append(aw.overview.Diagnostics, *msg.Diagnostic)
+
+		if e, ok := aw.overview.Resources[msg.Diagnostic.Address]; ok {
+			e.Message = msg.Diagnostic.Summary
+			if msg.Diagnostic.Severity == tfjson.DiagnosticSeverityError {
+				e.Status = ApplyStatusFailed
+			}
+			aw.broadcast(sseMessage("resource", e))
+		}
+	case "change_summary":
+		if msg.Changes != nil && msg.Changes.Operation != "plan" {
+			aw.overview.Summary = msg.Changes
+			aw.finish()
+		}
+	}
+}
+
+// Finish marks the apply as complete
+func (aw *ApplyWatcher) Finish() {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	aw.finish()
+}
+
+// Must hold aw.mu
+func (aw *ApplyWatcher) finish() {
+	if aw.overview.Done {
+		return
+	}
+	aw.overview.Done = true
+	aw.broadcast(sseMessage("done", aw.overview.Summary))
+}
+
+func (aw *ApplyWatcher) isDone() bool {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	return aw.overview.Done
+}
+
+// Consume reads apply -json messages until reader is exhausted
+func (aw *ApplyWatcher) Consume(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		msg, err := parsePlanLogMessage(scanner.Text())
+		if err != nil {
+			log.Printf("Skipping malformed apply log line: %s\n", err)
+			continue
+		}
+		if msg != nil {
+			aw.Handle(msg)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Follow reads apply -json messages from a file that is still being written,
+// until the apply change summary is found or ctx is cancelled
+func (aw *ApplyWatcher) Follow(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	partial := ""
+
+	for !aw.isDone() {
+		line, err := reader.ReadString('\n')
+		partial += line
+
+		if err == io.EOF {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		msg, err := parsePlanLogMessage(partial)
+		partial = ""
+		if err != nil {
+			log.Printf("Skipping malformed apply log line: %s\n", err)
+			continue
+		}
+		if msg != nil {
+			aw.Handle(msg)
+		}
+	}
+
+	return nil
+}
+
+func sseMessage(event string, v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b = []byte(fmt.Sprintf(`{"error": %q}`, err))
+	}
+	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event, b))
+}
+
+// ServeHTTP streams apply events to the browser as server-sent events
+func (aw *ApplyWatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	enableCors(&w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := aw.Subscribe()
+	defer aw.Unsubscribe(ch)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-ch:
+			// Dropped for falling behind, EventSource reconnects
+			if !ok {
+				return
+			}
+			w.Write(msg)
+			flusher.Flush()
+		}
+	}
+}
+
+// startApply runs terraform apply -json on the reviewed plan, or follows an apply log,
+// and feeds the events to r.Apply
+func (r *rover) startApply() error {
+	r.Apply = NewApplyWatcher(r.Plan)
+
+	// Follow apply run outside of rover
+	if r.ApplyLogPath != "" {
+		log.Printf("Following apply log %s...", r.ApplyLogPath)
+		go func() {
+			if err := r.Apply.Follow(context.Background(), r.ApplyLogPath); err != nil {
+				log.Printf("Unable to follow apply log (%s): %s\n", r.ApplyLogPath, err)
+			}
+			r.Apply.Finish()
+			log.Println("Apply log complete.")
+		}()
+		return nil
+	}
+
+	if r.ApplyPlanPath == "" {
+		return errors.New("Apply requires a plan file, use -planPath or let rover generate the plan")
+	}
+
+	cmd := exec.Command(r.TfPath, "apply", "-json", "-input=false", r.ApplyPlanPath)
+	cmd.Dir = r.WorkingDir
+	cmd.Stderr = os.Stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	log.Println("Applying plan...")
+	if err := cmd.Start(); err != nil {
+		return errors.New(fmt.Sprintf("Unable to run Apply: %s", err))
+	}
+
+	go func() {
+		if err := r.Apply.Consume(stdout); err != nil {
+			log.Printf("Unable to read Apply output: %s\n", err)
+		}
+		if err := cmd.Wait(); err != nil {
+			log.Printf("Apply failed: %s\n", err)
+		} else {
+			log.Println("Apply complete.")
+		}
+		r.Apply.Finish()
+
+		// Clean up plan generated by rover
+		if r.ApplyPlanPath != r.PlanPath {
+			os.RemoveAll(filepath.Dir(r.ApplyPlanPath))
+		}
+	}()
+
+	return nil
+}
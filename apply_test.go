@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// TestApplyFakeTerraform applies example/apply-test with the fake terraform script and
+// follows the apply over the server-sent events endpoint
+func TestApplyFakeTerraform(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake-terraform.sh requires a POSIX shell")
+	}
+	t.Setenv("FAKE_TF_DELAY", "0")
+
+	tfPath, err := filepath.Abs("example/apply-test/fake-terraform.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &rover{
+		WorkingDir: "example/apply-test",
+		TfPath:     tfPath,
+		PlanPath:   "plan.out",
+		ApplyRun:   true,
+	}
+
+	if err := r.getPlan(); err != nil {
+		t.Fatalf("getPlan() error = %v", err)
+	}
+	if r.ToolVersion != "1.5.5" {
+		t.Errorf("ToolVersion = %q, want 1.5.5", r.ToolVersion)
+	}
+	if len(r.Plan.ResourceChanges) != 3 {
+		t.Fatalf("resource changes = %d, want 3", len(r.Plan.ResourceChanges))
+	}
+
+	if err := r.startApply(); err != nil {
+		t.Fatalf("startApply() error = %v", err)
+	}
+
+	server := httptest.NewServer(r.Apply)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// Events up to the end of the apply, subscribers get the current state first
+	events := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "event: resource" {
+			events++
+		}
+		if line == "event: done" {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	if events == 0 {
+		t.Error("no resource events before done")
+	}
+
+	overview := r.Apply.Overview()
+	if !overview.Done {
+		t.Fatal("apply not done")
+	}
+
+	want := map[string]ApplyStatus{
+		"random_pet.server[0]": ApplyStatusDone,
+		"random_pet.server[1]": ApplyStatusFailed,
+		"random_id.token":      ApplyStatusDone,
+	}
+	for id, status := range want {
+		e, ok := overview.Resources[id]
+		if !ok {
+			t.Errorf("%s missing from apply overview", id)
+			continue
+		}
+		if e.Status != status {
+			t.Errorf("%s status = %s, want %s", id, e.Status, status)
+		}
+	}
+
+	if msg := overview.Resources["random_pet.server[1]"].Message; msg != "quota exceeded" {
+		t.Errorf("random_pet.server[1] message = %q, want quota exceeded", msg)
+	}
+	if overview.Summary == nil || overview.Summary.Add != 2 || overview.Summary.Operation != "apply" {
+		t.Errorf("summary = %+v, want 2 added by apply", overview.Summary)
+	}
+}
+
+func TestApplyWatcherSlowSubscriber(t *testing.T) {
+	plan := &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+		testResourceChange("aws_instance.web", tfjson.ActionCreate),
+	}}
+	aw := NewApplyWatcher(plan)
+
+	// Never reads, its buffer holds the current state plus 16 events
+	slow := aw.Subscribe()
+
+	hook := &planLogHook{Resource: planLogResource{Addr: "aws_instance.web"}, Action: "create"}
+	for i := 0; i < 32; i++ {
+		aw.Handle(&planLogMessage{Type: "apply_progress", Hook: hook})
+	}
+	aw.Handle(&planLogMessage{Type: "apply_complete", Hook: hook})
+	aw.Handle(&planLogMessage{Type: "change_summary", Changes: &ChangeSummary{Add: 1, Operation: "apply"}})
+
+	// The slow subscriber is dropped rather than missing the end of the apply
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != 17 {
+		t.Errorf("slow subscriber received %d events before being dropped, want 17", received)
+	}
+
+	// Reconnecting gets the final state
+	ch := aw.Subscribe()
+	defer aw.Unsubscribe(ch)
+
+	want := []string{
+		"event: resource\ndata: {\"id\":\"aws_instance.web\",\"status\":\"done\",\"action\":\"create\"}\n\n",
+		"event: done\ndata: {\"add\":1,\"change\":0,\"remove\":0,\"operation\":\"apply\"}\n\n",
+	}
+	for i, w := range want {
+		select {
+		case msg := <-ch:
+			if string(msg) != w {
+				t.Errorf("event %d = %q, want %q", i, msg, w)
+			}
+		default:
+			t.Fatalf("event %d missing, want %q", i, w)
+		}
+	}
+}
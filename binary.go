@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	// ToolTerraform denotes plans produced by Terraform
+	ToolTerraform string = "terraform"
+
+	// ToolOpenTofu denotes plans produced by OpenTofu
+	ToolOpenTofu string = "opentofu"
+)
+
+// FindTerraformBinary looks for terraform or tofu on PATH, then in the tfenv and
+// tofuenv shim directories
+func FindTerraformBinary() (string, error) {
+	for _, name := range []string{"terraform", "tofu"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+
+	candidates := []string{}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(home, ".tfenv", "bin", "terraform"),
+			filepath.Join(home, ".tofuenv", "bin", "tofu"),
+		)
+	}
+	candidates = append(candidates, "/bin/terraform", "/usr/local/bin/terraform", "/usr/local/bin/tofu")
+
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+
+	return "", errors.New("Unable to find terraform or tofu on PATH, use -tfPath to set the binary")
+}
+
+// binaryTool returns whether the binary at path is Terraform or OpenTofu
+func binaryTool(path string) string {
+	out, err := exec.Command(path, "version").Output()
+	if err == nil && strings.HasPrefix(string(out), "OpenTofu") {
+		return ToolOpenTofu
+	}
+	if err != nil && strings.HasPrefix(filepath.Base(path), "tofu") {
+		return ToolOpenTofu
+	}
+	return ToolTerraform
+}
+
+// providerTool returns the tool a provider address was installed by, e.g.
+// registry.opentofu.org/hashicorp/aws is only used by OpenTofu
+func providerTool(providerName string) string {
+	switch {
+	case strings.HasPrefix(providerName, "registry.opentofu.org/"):
+		return ToolOpenTofu
+	case strings.HasPrefix(providerName, "registry.terraform.io/"):
+		return ToolTerraform
+	}
+	return ""
+}
+
+// detectTool returns whether a plan or state read from a file was produced by Terraform or
+// OpenTofu, from the registry of its providers. Returns "" if there are no registry providers.
+func detectTool(plan *tfjson.Plan, state *tfjson.State) string {
+	providerNames := []string{}
+
+	var walkState func(module *tfjson.StateModule)
+	walkState = func(module *tfjson.StateModule) {
+		if module == nil {
+			return
+		}
+		for _, res := range module.Resources {
+			providerNames = append(providerNames, res.ProviderName)
+		}
+		for _, child := range module.ChildModules {
+			walkState(child)
+		}
+	}
+
+	if plan != nil {
+		for _, rc := range plan.ResourceChanges {
+			providerNames = append(providerNames, rc.ProviderName)
+		}
+		if plan.PriorState != nil && plan.PriorState.Values != nil {
+			walkState(plan.PriorState.Values.RootModule)
+		}
+	}
+	if state != nil && state.Values != nil {
+		walkState(state.Values.RootModule)
+	}
+
+	tool := ""
+	for _, name := range providerNames {
+		switch providerTool(name) {
+		case ToolOpenTofu:
+			return ToolOpenTofu
+		case ToolTerraform:
+			tool = ToolTerraform
+		}
+	}
+	return tool
+}
+
+// toolName returns the display name of tool
+func toolName(tool string) string {
+	if tool == ToolOpenTofu {
+		return "OpenTofu"
+	}
+	return "Terraform"
+}
+
+// newTerraform returns tfexec for dir, finding the binary first if -tfPath isn't set
+func (r *rover) newTerraform(dir string) (*tfexec.Terraform, error) {
+	if r.TfPath == "" {
+		path, err := FindTerraformBinary()
+		if err != nil {
+			return nil, err
+		}
+		r.TfPath = path
+	}
+
+	tf, err := tfexec.NewTerraform(dir, r.TfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Tool == "" {
+		r.Tool = binaryTool(r.TfPath)
+
+		v, _, err := tf.Version(context.Background(), false)
+		if err != nil {
+			log.Printf("Unable to get %s version (%s): %s\n", toolName(r.Tool), r.TfPath, err)
+		} else {
+			r.ToolVersion = v.String()
+		}
+
+		log.Printf("Using %s v%s (%s)...", toolName(r.Tool), r.ToolVersion, r.TfPath)
+	}
+
+	return tf, nil
+}
+
+// checkRequiredVersion checks the required_version constraints of the configuration in dir
+// against the version of the binary
+func (r *rover) checkRequiredVersion(dir string) error {
+	if r.ToolVersion == "" {
+		return nil
+	}
+
+	module, _ := tfconfig.LoadModule(dir)
+	if module == nil || len(module.RequiredCore) == 0 {
+		return nil
+	}
+
+	v, err := version.NewVersion(r.ToolVersion)
+	if err != nil {
+		return nil
+	}
+
+	constraint, err := version.NewConstraint(strings.Join(module.RequiredCore, ","))
+	if err != nil {
+		log.Printf("Unable to parse required_version (%s): %s\n", strings.Join(module.RequiredCore, ", "), err)
+		return nil
+	}
+
+	if !constraint.Check(v) {
+		return errors.New(fmt.Sprintf("%s v%s (%s) does not satisfy required_version %s", toolName(r.Tool), r.ToolVersion, r.TfPath, constraint))
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func writeFakeBinary(t *testing.T, dir string, name string, versionOutput string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	script := "#!/bin/sh\necho '" + versionOutput + "'\n"
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindTerraformBinary(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tofuDir := t.TempDir()
+	tofu := writeFakeBinary(t, tofuDir, "tofu", "OpenTofu v1.6.0")
+	bothDir := t.TempDir()
+	terraform := writeFakeBinary(t, bothDir, "terraform", "Terraform v1.5.5")
+	writeFakeBinary(t, bothDir, "tofu", "OpenTofu v1.6.0")
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"only tofu", tofuDir, tofu},
+		{"terraform first", bothDir, terraform},
+		{"terraform later on PATH", tofuDir + string(os.PathListSeparator) + bothDir, terraform},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("PATH", tc.path)
+
+			path, err := FindTerraformBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, path)
+			}
+		})
+	}
+}
+
+func TestBinaryTool(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"terraform", writeFakeBinary(t, dir, "terraform", "Terraform v1.5.5"), ToolTerraform},
+		{"opentofu", writeFakeBinary(t, dir, "tofu", "OpenTofu v1.6.0"), ToolOpenTofu},
+		{"renamed opentofu", writeFakeBinary(t, dir, "terraform-wrapper", "OpenTofu v1.6.0"), ToolOpenTofu},
+		{"failing tofu", filepath.Join(dir, "missing", "tofu"), ToolOpenTofu},
+		{"failing terraform", filepath.Join(dir, "missing", "terraform"), ToolTerraform},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tool := binaryTool(tc.path); tool != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, tool)
+			}
+		})
+	}
+}
+
+func TestCheckRequiredVersion(t *testing.T) {
+	dir := t.TempDir()
+	config := "terraform {\n  required_version = \">= 1.6.0, < 2.0.0\"\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	noConstraintDir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(noConstraintDir, "main.tf"), []byte("locals {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		dir       string
+		version   string
+		expectErr bool
+	}{
+		{"satisfied", dir, "1.6.2", false},
+		{"too old", dir, "1.5.5", true},
+		{"too new", dir, "2.0.0", true},
+		{"unknown version", dir, "", false},
+		{"no constraint", noConstraintDir, "1.5.5", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &rover{Tool: ToolOpenTofu, ToolVersion: tc.version, TfPath: "tofu"}
+
+			err := r.checkRequiredVersion(tc.dir)
+			if tc.expectErr && err == nil {
+				t.Error("expected an error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestDetectTool(t *testing.T) {
+	planJSON := `{
+  "format_version": "1.2",
+  "terraform_version": "1.8.0",
+  "resource_changes": [
+    {"address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main",
+     "provider_name": "registry.opentofu.org/hashicorp/aws",
+     "change": {"actions": ["create"], "before": null, "after": {}, "after_unknown": {}}}
+  ]
+}`
+
+	r := &rover{}
+	tofuPlan, err := r.readPlanJSON([]byte(planJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &tfjson.State{
+		Values: &tfjson.StateValues{
+			RootModule: &tfjson.StateModule{
+				ChildModules: []*tfjson.StateModule{
+					{Resources: []*tfjson.StateResource{{Address: "module.db.aws_db_instance.main", ProviderName: "registry.opentofu.org/hashicorp/aws"}}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		plan     *tfjson.Plan
+		state    *tfjson.State
+		expected string
+	}{
+		{"opentofu plan", tofuPlan, nil, ToolOpenTofu},
+		{"terraform plan", &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{{Address: "aws_vpc.main", ProviderName: "registry.terraform.io/hashicorp/aws"}}}, nil, ToolTerraform},
+		{"opentofu state in child module", nil, state, ToolOpenTofu},
+		{"no providers", &tfjson.Plan{}, nil, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tool := detectTool(tc.plan, tc.state); tool != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, tool)
+			}
+		})
+	}
+}
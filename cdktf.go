@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	CDKTFOutDir        string = "cdktf.out"
+	CDKTFManifestFile  string = "manifest.json"
+	CDKTFStackJSONFile string = "cdk.tf.json"
+)
+
+// For parsing cdktf.out/manifest.json
+type cdktfManifest struct {
+	Stacks map[string]cdktfManifestStack `json:"stacks"`
+}
+
+type cdktfManifestStack struct {
+	Name                 string   `json:"name"`
+	WorkingDirectory     string   `json:"workingDirectory"`
+	SynthesizedStackPath string   `json:"synthesizedStackPath"`
+	Dependencies         []string `json:"dependencies"`
+}
+
+// For parsing the construct metadata of resources in cdk.tf.json
+type cdktfStackJSON struct {
+	Resource map[string]map[string]cdktfBlock `json:"resource"`
+	Data     map[string]map[string]cdktfBlock `json:"data"`
+}
+
+type cdktfBlock struct {
+	Metadata struct {
+		Metadata struct {
+			Path     string `json:"path"`
+			UniqueID string `json:"uniqueId"`
+		} `json:"metadata"`
+	} `json:"//"`
+}
+
+// cdktfOutDir returns the cdktf.out directory for dir, which is either the
+// synthesized output directory itself or a CDKTF project containing it
+func cdktfOutDir(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "stacks")); err == nil && filepath.Base(filepath.Clean(dir)) == CDKTFOutDir {
+		return dir
+	}
+	if _, err := os.Stat(filepath.Join(dir, CDKTFOutDir, "stacks")); err == nil {
+		return filepath.Join(dir, CDKTFOutDir)
+	}
+	return ""
+}
+
+// findCDKTFOutDir returns the synthesized output directory if the working directory
+// is a CDKTF project without Terraform configuration of its own
+func (r *rover) findCDKTFOutDir() string {
+	tfFiles, _ := filepath.Glob(filepath.Join(r.WorkingDir, "*.tf"))
+	if len(tfFiles) > 0 {
+		return ""
+	}
+	return cdktfOutDir(r.WorkingDir)
+}
+
+// ReadCDKTFStacks returns the synthesized stacks in outDir, from manifest.json if present
+func ReadCDKTFStacks(outDir string) ([]cdktfManifestStack, error) {
+	manifestJSON, err := ioutil.ReadFile(filepath.Join(outDir, CDKTFManifestFile))
+	if err == nil {
+		manifest := cdktfManifest{}
+		if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to parse %s: %s", CDKTFManifestFile, err))
+		}
+
+		stacks := []cdktfManifestStack{}
+		for name, stack := range manifest.Stacks {
+			if stack.Name == "" {
+				stack.Name = name
+			}
+			if stack.WorkingDirectory == "" {
+				stack.WorkingDirectory = filepath.Join("stacks", name)
+			}
+			stacks = append(stacks, stack)
+		}
+
+		sort.Slice(stacks, func(i, j int) bool {
+			return stacks[i].Name < stacks[j].Name
+		})
+
+		return stacks, nil
+	}
+
+	// Older versions of cdktf don't write a manifest
+	stackFiles, err := filepath.Glob(filepath.Join(outDir, "stacks", "*", CDKTFStackJSONFile))
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := []cdktfManifestStack{}
+	for _, stackFile := range stackFiles {
+		stackDir := filepath.Dir(stackFile)
+		stacks = append(stacks, cdktfManifestStack{
+			Name:             filepath.Base(stackDir),
+			WorkingDirectory: filepath.Join("stacks", filepath.Base(stackDir)),
+		})
+	}
+
+	return stacks, nil
+}
+
+// CDKTFConstructPaths maps each resource address in a synthesized stack to its construct path
+func CDKTFConstructPaths(stackJSONPath string) (map[string]string, error) {
+	stackJSON, err := ioutil.ReadFile(stackJSONPath)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := cdktfStackJSON{}
+	if err := json.Unmarshal(stackJSON, &stack); err != nil {
+		return nil, err
+	}
+
+	paths := map[string]string{}
+
+	for resourceType, resources := range stack.Resource {
+		for name, res := range resources {
+			if res.Metadata.Metadata.Path != "" {
+				paths[fmt.Sprintf("%s.%s", resourceType, name)] = res.Metadata.Metadata.Path
+			}
+		}
+	}
+
+	for dataType, resources := range stack.Data {
+		for name, res := range resources {
+			if res.Metadata.Metadata.Path != "" {
+				paths[fmt.Sprintf("data.%s.%s", dataType, name)] = res.Metadata.Metadata.Path
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// constructScope returns the construct containing a resource, without the stack name,
+// e.g. mystack/network/subnets/public is in "network > subnets". Node IDs can't contain "/".
+func constructScope(constructPath string) string {
+	segments := strings.Split(constructPath, "/")
+	if len(segments) <= 2 {
+		return segments[0]
+	}
+	return strings.Join(segments[1:len(segments)-1], " > ")
+}
+
+// cdktfStackSource returns the source of a stack unit, relative to the working directory
+func cdktfStackSource(workingDir string, stackDir string) string {
+	root, _ := filepath.Abs(workingDir)
+	dir, _ := filepath.Abs(stackDir)
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	if strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return fmt.Sprintf("./%s", filepath.ToSlash(rel))
+}
+
+// getCDKTFPlan plans every synthesized CDKTF stack and combines them into a single plan
+func (r *rover) getCDKTFPlan(outDir string, tmpDir string) error {
+	if dir := cdktfOutDir(outDir); dir != "" {
+		outDir = dir
+	}
+
+	stacks, err := ReadCDKTFStacks(outDir)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to read CDKTF stacks: %s", err))
+	}
+	if len(stacks) == 0 {
+		return errors.New(fmt.Sprintf("No synthesized stacks found in %s, run cdktf synth first", outDir))
+	}
+
+	units := []*StackUnit{}
+	r.Units = map[string]string{}
+	r.ConstructPaths = map[string]string{}
+
+	names := []string{}
+	for _, stack := range stacks {
+		names = append(names, stack.Name)
+	}
+	keys := stackUnitKeys(names)
+
+	for _, stack := range stacks {
+		key := keys[stack.Name]
+		stackDir := filepath.Join(outDir, stack.WorkingDirectory)
+
+		unit := &StackUnit{
+			Key:    key,
+			Dir:    stackDir,
+			Source: cdktfStackSource(r.WorkingDir, stackDir),
+		}
+		for _, dep := range stack.Dependencies {
+			if depKey, ok := keys[dep]; ok {
+				unit.DependsOn = append(unit.DependsOn, depKey)
+			}
+		}
+
+		log.Printf("Generating plan for CDKTF stack %s...", stack.Name)
+
+		tf, err := r.newTerraform(stackDir)
+		if err != nil {
+			return err
+		}
+
+		unit.Plan, err = r.runPlan(tf, filepath.Join(tmpDir, fmt.Sprintf("%s.tfplan", key)))
+		if err != nil {
+			return errors.New(fmt.Sprintf("%s (stack %s)", err, stack.Name))
+		}
+		unit.Checks = r.Checks
+		unit.Deferred = r.Deferred
+
+		paths, err := CDKTFConstructPaths(filepath.Join(stackDir, CDKTFStackJSONFile))
+		if err != nil {
+			log.Printf("Unable to read construct paths of stack %s: %s\n", stack.Name, err)
+		}
+		for address, path := range paths {
+			r.ConstructPaths[fmt.Sprintf("module.%s.%s", key, address)] = path
+		}
+
+		units = append(units, unit)
+		r.Units[key] = stackDir
+	}
+
+	r.combineStackUnits(units)
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeCDKTFFixture(t *testing.T, outDir string, manifest string, stacks map[string]string) {
+	t.Helper()
+
+	if manifest != "" {
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(outDir, CDKTFManifestFile), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, stackJSON := range stacks {
+		dir := filepath.Join(outDir, "stacks", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, CDKTFStackJSONFile), []byte(stackJSON), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadCDKTFStacks(t *testing.T) {
+	manifest := `{
+  "version": "0.20.0",
+  "stacks": {
+    "network": {
+      "name": "network",
+      "workingDirectory": "stacks/network",
+      "synthesizedStackPath": "stacks/network/cdk.tf.json",
+      "dependencies": []
+    },
+    "app": {
+      "workingDirectory": "stacks/app",
+      "dependencies": ["network"]
+    },
+    "db": {}
+  }
+}`
+
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "build")
+	writeCDKTFFixture(t, outDir, manifest, map[string]string{"network": "{}", "app": "{}", "db": "{}"})
+
+	stacks, err := ReadCDKTFStacks(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []cdktfManifestStack{
+		{Name: "app", WorkingDirectory: "stacks/app", Dependencies: []string{"network"}},
+		{Name: "db", WorkingDirectory: filepath.Join("stacks", "db")},
+		{Name: "network", WorkingDirectory: "stacks/network", SynthesizedStackPath: "stacks/network/cdk.tf.json", Dependencies: []string{}},
+	}
+	if !reflect.DeepEqual(stacks, expected) {
+		t.Errorf("expected %+v, got %+v", expected, stacks)
+	}
+
+	// Without a manifest the stacks are found from their cdk.tf.json files
+	oldDir := filepath.Join(dir, "old", CDKTFOutDir)
+	writeCDKTFFixture(t, oldDir, "", map[string]string{"web": "{}"})
+
+	stacks, err = ReadCDKTFStacks(oldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []cdktfManifestStack{{Name: "web", WorkingDirectory: filepath.Join("stacks", "web")}}
+	if !reflect.DeepEqual(stacks, expected) {
+		t.Errorf("expected %+v, got %+v", expected, stacks)
+	}
+
+	if got := cdktfOutDir(filepath.Join(dir, "old")); got != oldDir {
+		t.Errorf("expected out dir %s, got %s", oldDir, got)
+	}
+	if got := cdktfOutDir(dir); got != "" {
+		t.Errorf("expected no out dir in %s, got %s", dir, got)
+	}
+}
+
+func TestCDKTFStackSource(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		workingDir string
+		stackDir   string
+		expected   string
+	}{
+		{"default out dir", dir, filepath.Join(dir, CDKTFOutDir, "stacks", "app"), "./cdktf.out/stacks/app"},
+		{"custom out dir", dir, filepath.Join(dir, "build", "synth", "stacks", "app"), "./build/synth/stacks/app"},
+		{"out dir outside working dir", filepath.Join(dir, "project"), filepath.Join(dir, "out", "stacks", "app"), "../out/stacks/app"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cdktfStackSource(tc.workingDir, tc.stackDir); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCDKTFConstructPaths(t *testing.T) {
+	stackJSON := `{
+  "resource": {
+    "aws_vpc": {
+      "main": {"//": {"metadata": {"path": "network/vpc/main", "uniqueId": "main"}}, "cidr_block": "10.0.0.0/16"}
+    },
+    "aws_subnet": {
+      "untracked": {"cidr_block": "10.0.1.0/24"}
+    }
+  },
+  "data": {
+    "aws_ami": {
+      "ubuntu": {"//": {"metadata": {"path": "network/ami", "uniqueId": "ubuntu"}}}
+    }
+  }
+}`
+
+	dir := t.TempDir()
+	writeCDKTFFixture(t, dir, "", map[string]string{"network": stackJSON})
+
+	paths, err := CDKTFConstructPaths(filepath.Join(dir, "stacks", "network", CDKTFStackJSONFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"aws_vpc.main":        "network/vpc/main",
+		"data.aws_ami.ubuntu": "network/ami",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+
+	if scope := constructScope("network/vpc/main"); scope != "vpc" {
+		t.Errorf("expected scope vpc, got %s", scope)
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// CheckStatus is the result of a check block, precondition, postcondition or validation
+type CheckStatus string
+
+const (
+	CheckPass    CheckStatus = "pass"
+	CheckFail    CheckStatus = "fail"
+	CheckUnknown CheckStatus = "unknown"
+	CheckError   CheckStatus = "error"
+)
+
+// Kinds of checkable objects in the plan's checks
+const (
+	CheckKindResource = "resource"
+	CheckKindOutput   = "output_value"
+	CheckKindCheck    = "check"
+	CheckKindVariable = "var"
+)
+
+// Condition is a condition with its error message, as written in the configuration
+type Condition struct {
+	Condition    string `json:"condition"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// CheckResult is an entry of the checks in a plan, for a checkable object in configuration
+type CheckResult struct {
+	Address   CheckAddress    `json:"address"`
+	Status    CheckStatus     `json:"status"`
+	Instances []CheckInstance `json:"instances,omitempty"`
+}
+
+type CheckAddress struct {
+	Kind      string `json:"kind"`
+	ToDisplay string `json:"to_display"`
+	Module    string `json:"module,omitempty"`
+}
+
+// CheckInstance is the result of the checks of an instance of a checkable object
+type CheckInstance struct {
+	Address  CheckAddress `json:"address"`
+	Status   CheckStatus  `json:"status"`
+	Problems []struct {
+		Message string `json:"message"`
+	} `json:"problems,omitempty"`
+}
+
+// CheckState is the check status of an object in the resource overview
+type CheckState struct {
+	Kind     string      `json:"kind"`
+	Module   string      `json:"module,omitempty"`
+	Status   CheckStatus `json:"status"`
+	Problems []string    `json:"problems,omitempty"`
+}
+
+// CheckConfig is a check block
+type CheckConfig struct {
+	Name       string             `json:"name"`
+	Pos        tfconfig.SourcePos `json:"pos"`
+	Assertions []Condition        `json:"assertions,omitempty"`
+	References []string           `json:"references,omitempty"`
+}
+
+var checksSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "check", LabelNames: []string{"name"}},
+	},
+}
+
+var checkSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "assert"},
+	},
+}
+
+var conditionSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "condition"},
+		{Name: "error_message"},
+	},
+}
+
+// configFiles returns the configuration files in dir, in native and JSON syntax
+func configFiles(dir string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tf"))
+	jsonFiles, _ := filepath.Glob(filepath.Join(dir, "*.tf.json"))
+	return append(files, jsonFiles...)
+}
+
+// parseConfigFile reads and parses a configuration file in native or JSON syntax
+func parseConfigFile(parser *hclparse.Parser, fname string) (*hcl.File, []byte, error) {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(fname, ".json") {
+		file, diags = parser.ParseJSON(src, fname)
+	} else {
+		file, diags = parser.ParseHCL(src, fname)
+	}
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	return file, src, nil
+}
+
+// expressionSource returns the source of an expression as written in native syntax. In JSON
+// syntax expressions are strings, e.g. "${var.count > 0}" is var.count > 0.
+func expressionSource(expr hcl.Expression, src []byte) string {
+	source := strings.TrimSpace(string(expr.Range().SliceBytes(src)))
+	if !strings.HasSuffix(expr.Range().Filename, ".json") {
+		return source
+	}
+
+	var str string
+	if err := json.Unmarshal([]byte(source), &str); err != nil {
+		return source
+	}
+	if strings.HasPrefix(str, "${") && strings.HasSuffix(str, "}") && strings.Count(str, "${") == 1 {
+		return strings.TrimSpace(str[2 : len(str)-1])
+	}
+	return str
+}
+
+// parseCondition reads a validation, assert, precondition or postcondition block
+func parseCondition(block *hcl.Block, src []byte) (Condition, hcl.Expression, bool) {
+	attrs, _, _ := block.Body.PartialContent(conditionSchema)
+
+	condition, ok := attrs.Attributes["condition"]
+	if !ok {
+		return Condition{}, nil, false
+	}
+
+	c := Condition{
+		Condition: expressionSource(condition.Expr, src),
+	}
+
+	if errorMessage, ok := attrs.Attributes["error_message"]; ok {
+		val, diags := errorMessage.Expr.Value(nil)
+		if !diags.HasErrors() && val.IsKnown() && !val.IsNull() {
+			c.ErrorMessage = val.AsString()
+		} else {
+			c.ErrorMessage = expressionSource(errorMessage.Expr, src)
+		}
+	}
+
+	return c, condition.Expr, true
+}
+
+// expressionReferences returns the objects an expression refers to, like the references
+// of expressions in the plan's configuration
+func expressionReferences(expr hcl.Expression) []string {
+	references := []string{}
+
+	for _, traversal := range expr.Variables() {
+		parts := []string{traversal.RootName()}
+		for _, step := range traversal[1:] {
+			if attr, ok := step.(hcl.TraverseAttr); ok {
+				parts = append(parts, attr.Name)
+			} else {
+				break
+			}
+		}
+
+		length := 2
+		switch parts[0] {
+		case "each", "count", "self", "path", "terraform":
+			continue
+		case "data":
+			length = 3
+		}
+		if len(parts) < length {
+			continue
+		}
+
+		references = append(references, strings.Join(parts[:length], "."))
+	}
+
+	return references
+}
+
+// CheckConfigs returns the check blocks declared in dir. tfconfig and the plan don't include them.
+func CheckConfigs(dir string) map[string]*CheckConfig {
+	checks := map[string]*CheckConfig{}
+
+	parser := hclparse.NewParser()
+
+	for _, fname := range configFiles(dir) {
+		file, src, err := parseConfigFile(parser, fname)
+		if err != nil {
+			continue
+		}
+
+		content, _, _ := file.Body.PartialContent(checksSchema)
+		for _, block := range content.Blocks {
+			check := &CheckConfig{
+				Name: block.Labels[0],
+				Pos: tfconfig.SourcePos{
+					Filename: fname,
+					Line:     block.DefRange.Start.Line,
+				},
+			}
+
+			body, _, _ := block.Body.PartialContent(checkSchema)
+			for _, assertBlock := range body.Blocks {
+				if assertion, expr, ok := parseCondition(assertBlock, src); ok {
+					check.Assertions = append(check.Assertions, assertion)
+					check.References = append(check.References, expressionReferences(expr)...)
+				}
+			}
+
+			checks[check.Name] = check
+		}
+	}
+
+	return checks
+}
+
+// checkExpressions returns the references of a check block's assertions as expressions,
+// so edges are drawn to the objects it guards
+func checkExpressions(check *CheckConfig) map[string]*tfjson.Expression {
+	return map[string]*tfjson.Expression{
+		"assert": {
+			ExpressionData: &tfjson.ExpressionData{
+				References: check.References,
+			},
+		},
+	}
+}
+
+// checkClasses returns graph node classes for the check status of an object
+func checkClasses(check *CheckState) []string {
+	if check == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("check-%s", check.Status)}
+}
+
+// PopulateChecks adds check blocks to the configuration and state, and the check results
+// of the plan to the objects they guard
+func (r *rover) PopulateChecks(rso *ResourcesOverview) {
+	rs := rso.States
+	rc := rso.Configs
+	rso.Checks = map[string]*CheckState{}
+
+	// Check blocks
+	for moduleId, dir := range moduleDirs(rso) {
+		if dir == "" {
+			continue
+		}
+
+		prefix := moduleId
+		if prefix != "" {
+			prefix = fmt.Sprintf("%s.", prefix)
+		}
+
+		for name, check := range CheckConfigs(dir) {
+			rc[fmt.Sprintf("%scheck.%s", prefix, name)] = &ConfigOverview{
+				CheckConfig: check,
+			}
+		}
+	}
+
+	// Add check blocks to the modules declaring them
+	for moduleId, module := range rs {
+		if module.Type != ResourceTypeModule || module.IsParent {
+			continue
+		}
+
+		prefix := moduleId
+		configPrefix := matchBrackets.ReplaceAllString(moduleId, "")
+		if moduleId != "" {
+			prefix = fmt.Sprintf("%s.", prefix)
+			configPrefix = fmt.Sprintf("%s.", configPrefix)
+		}
+
+		for configId, config := range rc {
+			if config.CheckConfig == nil || configId != fmt.Sprintf("%scheck.%s", configPrefix, config.CheckConfig.Name) {
+				continue
+			}
+
+			id := fmt.Sprintf("%scheck.%s", prefix, config.CheckConfig.Name)
+			rs[id] = &StateOverview{
+				Type: ResourceTypeCheck,
+			}
+			if module.Children == nil {
+				module.Children = map[string]*StateOverview{}
+			}
+			module.Children[id] = rs[id]
+		}
+	}
+
+	// Results
+	for _, result := range r.Checks {
+		instances := result.Instances
+		if len(instances) == 0 {
+			instances = []CheckInstance{{Address: result.Address, Status: result.Status}}
+		}
+
+		for _, instance := range instances {
+			check := &CheckState{
+				Kind:   result.Address.Kind,
+				Module: instance.Address.Module,
+				Status: instance.Status,
+			}
+			for _, problem := range instance.Problems {
+				check.Problems = append(check.Problems, problem.Message)
+			}
+
+			id := instance.Address.ToDisplay
+			rso.Checks[id] = check
+
+			switch check.Kind {
+			case CheckKindResource:
+				if state, ok := rs[id]; ok {
+					state.Check = check
+				}
+			case CheckKindCheck:
+				// Check blocks without configuration, e.g. when reading a plan file
+				if _, ok := rs[id]; !ok {
+					rs[id] = &StateOverview{
+						Type: ResourceTypeCheck,
+					}
+					if module, ok := rs[check.Module]; ok && module.Children != nil {
+						module.Children[id] = rs[id]
+					}
+				}
+				rs[id].Check = check
+			case CheckKindOutput:
+				// Root module outputs are keyed by name
+				if state, ok := rs[strings.TrimPrefix(id, "output.")]; ok && check.Module == "" {
+					state.Check = check
+				} else if state, ok := rs[id]; ok {
+					state.Check = check
+				}
+			}
+
+			if check.Status == CheckFail || check.Status == CheckError {
+				if rso.Summary == nil {
+					rso.Summary = &ChangeSummary{}
+				}
+				rso.Summary.Failed++
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestCheckConfigs(t *testing.T) {
+	dir := t.TempDir()
+	native := `check "health" {
+  assert {
+    condition     = aws_lb.web.arn != ""
+    error_message = "No load balancer."
+  }
+}
+`
+	jsonConfig := `{
+  "check": {
+    "certificate": {
+      "assert": [
+        {
+          "condition": "${data.aws_acm_certificate.web.status == \"ISSUED\"}",
+          "error_message": "Certificate not issued."
+        },
+        {
+          "condition": "${var.domain != \"\"}"
+        }
+      ]
+    }
+  }
+}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "checks.tf"), []byte(native), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "checks.tf.json"), []byte(jsonConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := CheckConfigs(dir)
+
+	health, ok := checks["health"]
+	if !ok {
+		t.Fatalf("expected health check from checks.tf, got %v", checks)
+	}
+	if want := []Condition{{Condition: `aws_lb.web.arn != ""`, ErrorMessage: "No load balancer."}}; !reflect.DeepEqual(health.Assertions, want) {
+		t.Errorf("health assertions = %+v, want %+v", health.Assertions, want)
+	}
+	if want := []string{"aws_lb.web"}; !reflect.DeepEqual(health.References, want) {
+		t.Errorf("health references = %q, want %q", health.References, want)
+	}
+
+	certificate, ok := checks["certificate"]
+	if !ok {
+		t.Fatalf("expected certificate check from checks.tf.json, got %v", checks)
+	}
+	want := []Condition{
+		{Condition: `data.aws_acm_certificate.web.status == "ISSUED"`, ErrorMessage: "Certificate not issued."},
+		{Condition: `var.domain != ""`},
+	}
+	if !reflect.DeepEqual(certificate.Assertions, want) {
+		t.Errorf("certificate assertions = %+v, want %+v", certificate.Assertions, want)
+	}
+	if want := []string{"data.aws_acm_certificate.web", "var.domain"}; !reflect.DeepEqual(certificate.References, want) {
+		t.Errorf("certificate references = %q, want %q", certificate.References, want)
+	}
+	if certificate.Pos.Filename != filepath.Join(dir, "checks.tf.json") || certificate.Pos.Line != 3 {
+		t.Errorf("certificate pos = %+v", certificate.Pos)
+	}
+}
+
+func TestPopulateChecks(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, ".terraform", "modules", "app.storage")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := `check "bucket" {
+  assert {
+    condition = aws_s3_bucket.logs.versioning[0].enabled
+  }
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(nested, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := func(kind string, address string, module string) *CheckResult {
+		return &CheckResult{
+			Address: CheckAddress{Kind: kind, ToDisplay: address, Module: module},
+			Status:  CheckFail,
+		}
+	}
+
+	// The nested module is only in modules.json, and there is no change summary
+	r := &rover{
+		Checks: []*CheckResult{
+			failed(CheckKindCheck, "module.app.module.storage.check.bucket", "module.app.module.storage"),
+			failed(CheckKindResource, "aws_instance.web", ""),
+			{Address: CheckAddress{Kind: CheckKindOutput, ToDisplay: "output.id"}, Status: CheckPass},
+		},
+	}
+	rso := &ResourcesOverview{
+		Locations: map[string]string{"app.storage": nested},
+		Configs: map[string]*ConfigOverview{
+			"module.app": {ModuleConfig: &tfjson.ModuleCall{}},
+		},
+		States: map[string]*StateOverview{
+			"module.app.module.storage": {Type: ResourceTypeModule, Children: map[string]*StateOverview{}},
+			"aws_instance.web":          {Type: ResourceTypeResource},
+		},
+	}
+
+	r.PopulateChecks(rso)
+
+	if config, ok := rso.Configs["module.app.module.storage.check.bucket"]; !ok || config.CheckConfig == nil {
+		t.Errorf("expected the check block of the nested module, got %v", rso.Configs)
+	}
+	if state := rso.States["module.app.module.storage.check.bucket"]; state == nil || state.Check == nil || state.Check.Status != CheckFail {
+		t.Errorf("expected the failed check on the nested module's check block, got %+v", state)
+	}
+	if rso.Summary == nil || rso.Summary.Failed != 2 {
+		t.Errorf("expected 2 failed checks in the summary, got %+v", rso.Summary)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Compliance rules that a resource can violate
+const (
+	RuleRequiredTags = "required_tags"
+	RuleResourceName = "resource_name"
+	RuleNameValue    = "name"
+)
+
+// DefaultTagAttributes are the attributes holding the tags or labels of taggable resources
+var DefaultTagAttributes = []string{"tags", "tags_all", "labels", "effective_labels"}
+
+// ComplianceRules are the tagging and naming conventions of -complianceRules. Resources
+// with one of the tag attributes must carry the required tags, plus those required for
+// their type. Naming rules are keyed by resource type, or * for all types.
+type ComplianceRules struct {
+	RequiredTags  []string               `json:"required_tags,omitempty"`
+	ResourceTags  map[string][]string    `json:"resource_tags,omitempty"`
+	TagAttributes []string               `json:"tag_attributes,omitempty"`
+	Naming        map[string]*NamingRule `json:"naming,omitempty"`
+}
+
+// NamingRule is a regular expression for the name of resources in their address, and one
+// for the value of their name attribute (name unless Attribute is set, e.g. bucket)
+type NamingRule struct {
+	ResourceName string `json:"resource_name,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Attribute    string `json:"attribute,omitempty"`
+
+	resourceName *regexp.Regexp
+	name         *regexp.Regexp
+}
+
+// Violation is a tagging or naming convention a resource doesn't follow
+type Violation struct {
+	Address string `json:"address"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ComplianceReport lists the violations of the managed resource instances that exist after
+// the plan, or in the state, whether they change or not
+type ComplianceReport struct {
+	Checked    int          `json:"checked"`
+	Compliant  int          `json:"compliant"`
+	Violations []*Violation `json:"violations"`
+}
+
+// getComplianceRules reads and compiles the rules of -complianceRules
+func (r *rover) getComplianceRules() error {
+	if r.RulesPath == "" {
+		return nil
+	}
+
+	log.Println("Using provided compliance rules...")
+
+	rulesJSON, err := ioutil.ReadFile(r.RulesPath)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to read compliance rules (%s): %s", r.RulesPath, err))
+	}
+
+	rules := &ComplianceRules{}
+	if err := json.Unmarshal(rulesJSON, rules); err != nil {
+		return errors.New(fmt.Sprintf("Unable to read compliance rules (%s): %s", r.RulesPath, err))
+	}
+
+	if len(rules.TagAttributes) == 0 {
+		rules.TagAttributes = DefaultTagAttributes
+	}
+
+	for resourceType, naming := range rules.Naming {
+		if naming.ResourceName != "" {
+			if naming.resourceName, err = regexp.Compile(naming.ResourceName); err != nil {
+				return errors.New(fmt.Sprintf("Invalid resource_name pattern for %s: %s", resourceType, err))
+			}
+		}
+		if naming.Name != "" {
+			if naming.name, err = regexp.Compile(naming.Name); err != nil {
+				return errors.New(fmt.Sprintf("Invalid name pattern for %s: %s", resourceType, err))
+			}
+		}
+		if naming.Attribute == "" {
+			naming.Attribute = "name"
+		}
+	}
+
+	r.Rules = rules
+
+	return nil
+}
+
+// resourceTags returns the tags of a resource from its tag attributes, and whether it is
+// taggable
+func (rules *ComplianceRules) resourceTags(values map[string]interface{}) (map[string]bool, bool) {
+	tags := map[string]bool{}
+	taggable := false
+
+	for _, attribute := range rules.TagAttributes {
+		value, ok := values[attribute]
+		if !ok {
+			continue
+		}
+		taggable = true
+
+		if m, ok := value.(map[string]interface{}); ok {
+			for key := range m {
+				tags[key] = true
+			}
+		}
+	}
+
+	return tags, taggable
+}
+
+// check returns the violations of a resource's planned values
+func (rules *ComplianceRules) check(address string, resource *tfjson.ConfigResource, values map[string]interface{}) []*Violation {
+	violations := []*Violation{}
+
+	if tags, taggable := rules.resourceTags(values); taggable {
+		missing := []string{}
+		for _, tag := range append(append([]string{}, rules.RequiredTags...), rules.ResourceTags[resource.Type]...) {
+			if !tags[tag] {
+				missing = append(missing, tag)
+			}
+		}
+		if len(missing) > 0 {
+			violations = append(violations, &Violation{
+				Address: address,
+				Rule:    RuleRequiredTags,
+				Message: fmt.Sprintf("Missing required tags: %s", strings.Join(missing, ", ")),
+			})
+		}
+	}
+
+	for _, key := range []string{resource.Type, "*"} {
+		naming, ok := rules.Naming[key]
+		if !ok {
+			continue
+		}
+
+		if naming.resourceName != nil && !naming.resourceName.MatchString(resource.Name) {
+			violations = append(violations, &Violation{
+				Address: address,
+				Rule:    RuleResourceName,
+				Message: fmt.Sprintf("Resource name %s doesn't match %s", resource.Name, naming.ResourceName),
+			})
+		}
+
+		// Unknown names can't be checked until apply
+		if name, ok := values[naming.Attribute].(string); ok && naming.name != nil && !naming.name.MatchString(name) {
+			violations = append(violations, &Violation{
+				Address: address,
+				Rule:    RuleNameValue,
+				Message: fmt.Sprintf("%s %s doesn't match %s", naming.Attribute, name, naming.Name),
+			})
+		}
+
+		// Rules for the type replace the rules for all types
+		break
+	}
+
+	return violations
+}
+
+// PopulateCompliance checks the planned values of every managed resource instance against
+// the compliance rules, including those without changes. Resources that are deleted or
+// forgotten have no planned values and are skipped.
+func (r *rover) PopulateCompliance(rso *ResourcesOverview) {
+	if r.Rules == nil {
+		return
+	}
+
+	log.Println("Checking compliance...")
+
+	rs := rso.States
+	rc := rso.Configs
+
+	report := &ComplianceReport{
+		Violations: []*Violation{},
+	}
+
+	for id, state := range rs {
+		if state.Type != ResourceTypeResource || len(state.Children) > 0 {
+			continue
+		}
+
+		config, ok := rc[matchBrackets.ReplaceAllString(id, "")]
+		if !ok || config.ResourceConfig == nil {
+			continue
+		}
+
+		values, ok := state.Change.After.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		report.Checked++
+		state.Violations = r.Rules.check(id, config.ResourceConfig, values)
+		if len(state.Violations) == 0 {
+			report.Compliant++
+			continue
+		}
+		report.Violations = append(report.Violations, state.Violations...)
+	}
+
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		return report.Violations[i].Address < report.Violations[j].Address
+	})
+
+	r.Compliance = report
+}
+
+// violationClasses returns the graph node class of resources that violate compliance rules
+func violationClasses(violations []*Violation) []string {
+	if len(violations) == 0 {
+		return nil
+	}
+	return []string{"non-compliant"}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestComplianceRulesCheck(t *testing.T) {
+	rules := &ComplianceRules{
+		RequiredTags:  []string{"owner"},
+		ResourceTags:  map[string][]string{"aws_db_instance": {"backup"}},
+		TagAttributes: DefaultTagAttributes,
+		Naming: map[string]*NamingRule{
+			"aws_s3_bucket": {
+				ResourceName: "^[a-z_]+$",
+				Name:         "^acme-",
+				Attribute:    "bucket",
+				resourceName: regexp.MustCompile("^[a-z_]+$"),
+				name:         regexp.MustCompile("^acme-"),
+			},
+			"*": {
+				ResourceName: "^[a-z]+$",
+				Name:         "^[a-z-]+$",
+				Attribute:    "name",
+				resourceName: regexp.MustCompile("^[a-z]+$"),
+				name:         regexp.MustCompile("^[a-z-]+$"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		resource *tfjson.ConfigResource
+		values   map[string]interface{}
+		want     []Violation
+	}{
+		{
+			name:     "compliant",
+			resource: &tfjson.ConfigResource{Type: "aws_instance", Name: "web"},
+			values:   map[string]interface{}{"name": "web-one", "tags": map[string]interface{}{"owner": "ops"}},
+			want:     []Violation{},
+		},
+		{
+			name:     "missing tags",
+			resource: &tfjson.ConfigResource{Type: "aws_db_instance", Name: "main"},
+			values:   map[string]interface{}{"tags": nil, "tags_all": map[string]interface{}{"env": "prod"}},
+			want: []Violation{
+				{Address: "r", Rule: RuleRequiredTags, Message: "Missing required tags: owner, backup"},
+			},
+		},
+		{
+			name:     "tags of any tag attribute",
+			resource: &tfjson.ConfigResource{Type: "google_storage_bucket", Name: "logs"},
+			values:   map[string]interface{}{"labels": map[string]interface{}{"env": "prod"}, "effective_labels": map[string]interface{}{"owner": "ops"}},
+			want:     []Violation{},
+		},
+		{
+			name:     "not taggable",
+			resource: &tfjson.ConfigResource{Type: "aws_iam_role_policy", Name: "ci"},
+			values:   map[string]interface{}{"policy": "{}"},
+			want:     []Violation{},
+		},
+		{
+			name:     "naming rules for the type replace those for all types",
+			resource: &tfjson.ConfigResource{Type: "aws_s3_bucket", Name: "app_logs"},
+			values:   map[string]interface{}{"bucket": "logs", "name": "Logs", "tags": map[string]interface{}{"owner": "ops"}},
+			want: []Violation{
+				{Address: "r", Rule: RuleNameValue, Message: "bucket logs doesn't match ^acme-"},
+			},
+		},
+		{
+			name:     "naming rules for all types",
+			resource: &tfjson.ConfigResource{Type: "aws_instance", Name: "Web1"},
+			values:   map[string]interface{}{"name": "Web_1", "tags": map[string]interface{}{"owner": "ops"}},
+			want: []Violation{
+				{Address: "r", Rule: RuleResourceName, Message: "Resource name Web1 doesn't match ^[a-z]+$"},
+				{Address: "r", Rule: RuleNameValue, Message: "name Web_1 doesn't match ^[a-z-]+$"},
+			},
+		},
+		{
+			name:     "unknown name",
+			resource: &tfjson.ConfigResource{Type: "aws_instance", Name: "web"},
+			values:   map[string]interface{}{"tags": map[string]interface{}{"owner": "ops"}},
+			want:     []Violation{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []Violation{}
+			for _, violation := range rules.check("r", tt.resource, tt.values) {
+				got = append(got, *violation)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPopulateComplianceUnchanged(t *testing.T) {
+	r := &rover{Rules: &ComplianceRules{RequiredTags: []string{"owner"}, TagAttributes: DefaultTagAttributes}}
+	rso := &ResourcesOverview{
+		Configs: map[string]*ConfigOverview{
+			"aws_instance.web": {ResourceConfig: &tfjson.ConfigResource{Type: "aws_instance", Name: "web"}},
+			"aws_instance.old": {ResourceConfig: &tfjson.ConfigResource{Type: "aws_instance", Name: "old"}},
+		},
+		States: map[string]*StateOverview{
+			// No-op changes are checked
+			"aws_instance.web": {Type: ResourceTypeResource, Change: tfjson.Change{
+				Actions: tfjson.Actions{tfjson.ActionNoop},
+				After:   map[string]interface{}{"tags": map[string]interface{}{}},
+			}},
+			"aws_instance.old": {Type: ResourceTypeResource, Change: tfjson.Change{
+				Actions: tfjson.Actions{tfjson.ActionDelete},
+				Before:  map[string]interface{}{"tags": map[string]interface{}{}},
+			}},
+		},
+	}
+
+	r.PopulateCompliance(rso)
+
+	if r.Compliance.Checked != 1 || len(r.Compliance.Violations) != 1 || r.Compliance.Violations[0].Address != "aws_instance.web" {
+		t.Errorf("compliance = %d checked, violations %+v, want aws_instance.web only", r.Compliance.Checked, r.Compliance.Violations)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"strconv"
+)
+
+// Cost is the monthly cost of a resource, module or file before and after the plan
+type Cost struct {
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Delta  float64 `json:"delta"`
+}
+
+// InfracostOutput is the JSON output of infracost breakdown or infracost diff
+type InfracostOutput struct {
+	Currency string             `json:"currency"`
+	Projects []InfracostProject `json:"projects"`
+}
+
+// InfracostProject is a project of an Infracost breakdown. The past breakdown is the cost
+// of the prior state.
+type InfracostProject struct {
+	Name          string              `json:"name"`
+	PastBreakdown *InfracostBreakdown `json:"pastBreakdown"`
+	Breakdown     *InfracostBreakdown `json:"breakdown"`
+}
+
+// InfracostBreakdown lists the costs of the resources of a project
+type InfracostBreakdown struct {
+	Resources []InfracostResource `json:"resources"`
+}
+
+// InfracostResource is the cost of a resource instance, including its subresources
+type InfracostResource struct {
+	Name        string  `json:"name"`
+	MonthlyCost *string `json:"monthlyCost"`
+}
+
+// getInfracost reads the Infracost output of -infracostJSON
+func (r *rover) getInfracost() error {
+	if r.InfracostPath == "" {
+		return nil
+	}
+
+	log.Println("Using provided Infracost output...")
+
+	infracostJSON, err := ioutil.ReadFile(r.InfracostPath)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to read Infracost output (%s): %s", r.InfracostPath, err))
+	}
+
+	r.Infracost = &InfracostOutput{}
+	if err := json.Unmarshal(infracostJSON, r.Infracost); err != nil {
+		return errors.New(fmt.Sprintf("Unable to read Infracost output (%s): %s", r.InfracostPath, err))
+	}
+
+	return nil
+}
+
+// monthlyCosts returns the monthly cost of each resource of a breakdown, by address
+func monthlyCosts(breakdown *InfracostBreakdown) map[string]float64 {
+	costs := map[string]float64{}
+	if breakdown == nil {
+		return costs
+	}
+
+	for _, resource := range breakdown.Resources {
+		if resource.MonthlyCost == nil {
+			continue
+		}
+		if cost, err := strconv.ParseFloat(*resource.MonthlyCost, 64); err == nil {
+			costs[resource.Name] += cost
+		}
+	}
+	return costs
+}
+
+// addCost adds a cost to a total, which is created if nil
+func addCost(total *Cost, cost *Cost) *Cost {
+	if cost == nil {
+		return total
+	}
+	if total == nil {
+		total = &Cost{}
+	}
+	// Rounded to cents, as sums of floats drift
+	total.Before = math.Round((total.Before+cost.Before)*100) / 100
+	total.After = math.Round((total.After+cost.After)*100) / 100
+	total.Delta = math.Round((total.After-total.Before)*100) / 100
+	return total
+}
+
+// PopulateCosts adds the monthly cost of each resource instance in the Infracost output, or
+// estimated from the price table, to the state overview. Resources with count or for_each
+// get the total of their instances.
+func (r *rover) PopulateCosts(rso *ResourcesOverview) {
+	if r.Infracost == nil && r.Prices == nil {
+		return
+	}
+
+	rs := rso.States
+
+	var projects []InfracostProject
+	if r.Infracost != nil {
+		rso.Currency = r.Infracost.Currency
+		projects = r.Infracost.Projects
+	}
+
+	for _, project := range projects {
+		before := monthlyCosts(project.PastBreakdown)
+		after := monthlyCosts(project.Breakdown)
+
+		addresses := map[string]bool{}
+		for address := range before {
+			addresses[address] = true
+		}
+		for address := range after {
+			addresses[address] = true
+		}
+
+		for address := range addresses {
+			state, ok := rs[address]
+			if !ok {
+				continue
+			}
+			state.Cost = addCost(state.Cost, &Cost{
+				Before: before[address],
+				After:  after[address],
+			})
+		}
+	}
+
+	r.PopulatePrices(rso)
+
+	for _, state := range rs {
+		if (state.Type != ResourceTypeResource && state.Type != ResourceTypeData) || len(state.Children) == 0 {
+			continue
+		}
+		for _, child := range state.Children {
+			state.Cost = addCost(state.Cost, child.Cost)
+		}
+	}
+}
+
+// summarizeCost adds the total monthly cost delta of the resource instances to the change
+// summary, if any of them are priced. Resources with count or for_each are left out, their
+// cost is the total of their instances.
+func summarizeCost(rso *ResourcesOverview) {
+	if rso.Summary == nil {
+		return
+	}
+
+	var total *Cost
+	for _, state := range rso.States {
+		if (state.Type != ResourceTypeResource && state.Type != ResourceTypeData) || len(state.Children) > 0 {
+			continue
+		}
+		total = addCost(total, state.Cost)
+	}
+
+	if total != nil {
+		rso.Summary.CostDelta = &total.Delta
+	}
+}
+
+// rollupCost sets the cost of modules and files in the map to the total of their
+// resources
+func rollupCost(re *Resource) *Cost {
+	if len(re.Children) == 0 || re.Type == ResourceTypeResource || re.Type == ResourceTypeData {
+		return re.Cost
+	}
+
+	var total *Cost
+	for _, child := range re.Children {
+		total = addCost(total, rollupCost(child))
+	}
+	re.Cost = total
+	return total
+}
+
+// costClasses returns the graph node class of resources, modules and files whose monthly
+// cost changes
+func costClasses(cost *Cost) []string {
+	if cost == nil || math.Abs(cost.Delta) < 0.005 {
+		return nil
+	}
+	if cost.Delta > 0 {
+		return []string{"cost-increase"}
+	}
+	return []string{"cost-decrease"}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestMonthlyCosts(t *testing.T) {
+	cost := func(monthly string) *string {
+		return &monthly
+	}
+
+	tests := []struct {
+		name      string
+		breakdown *InfracostBreakdown
+		want      map[string]float64
+	}{
+		{"no breakdown", nil, map[string]float64{}},
+		{
+			name: "costs",
+			breakdown: &InfracostBreakdown{Resources: []InfracostResource{
+				{Name: "aws_instance.web[0]", MonthlyCost: cost("7.592")},
+				{Name: "aws_instance.web[1]", MonthlyCost: cost("7.592")},
+				{Name: "aws_db_instance.main", MonthlyCost: cost("0")},
+			}},
+			want: map[string]float64{"aws_instance.web[0]": 7.592, "aws_instance.web[1]": 7.592, "aws_db_instance.main": 0},
+		},
+		{
+			name: "usage based and non-numeric costs are left out",
+			breakdown: &InfracostBreakdown{Resources: []InfracostResource{
+				{Name: "aws_lambda_function.api", MonthlyCost: nil},
+				{Name: "aws_s3_bucket.logs", MonthlyCost: cost("N/A")},
+				{Name: "aws_instance.web", MonthlyCost: cost("12.5")},
+			}},
+			want: map[string]float64{"aws_instance.web": 12.5},
+		},
+		{
+			name: "same resource in several entries",
+			breakdown: &InfracostBreakdown{Resources: []InfracostResource{
+				{Name: "aws_instance.web", MonthlyCost: cost("10")},
+				{Name: "aws_instance.web", MonthlyCost: cost("2.5")},
+			}},
+			want: map[string]float64{"aws_instance.web": 12.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := monthlyCosts(tt.breakdown); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("monthlyCosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPopulateCosts(t *testing.T) {
+	infracost := `{
+  "currency": "EUR",
+  "projects": [
+    {
+      "name": "main",
+      "pastBreakdown": {"resources": [
+        {"name": "aws_instance.web[0]", "monthlyCost": "10"}
+      ]},
+      "breakdown": {"resources": [
+        {"name": "aws_instance.web[0]", "monthlyCost": "10"},
+        {"name": "aws_instance.web[1]", "monthlyCost": "10"},
+        {"name": "aws_s3_bucket.logs", "monthlyCost": null}
+      ]}
+    }
+  ]
+}`
+
+	r := &rover{Infracost: &InfracostOutput{}, Prices: &PriceTable{}}
+	if err := json.Unmarshal([]byte(infracost), r.Infracost); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(testPriceTable), r.Prices); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := func(before, after interface{}) *StateOverview {
+		return &StateOverview{Type: ResourceTypeResource, Change: tfjson.Change{Before: before, After: after}}
+	}
+	resource := func(resourceType string) *ConfigOverview {
+		return &ConfigOverview{ResourceConfig: &tfjson.ConfigResource{Type: resourceType}}
+	}
+
+	web0 := instance(map[string]interface{}{"instance_type": "t3.micro"}, map[string]interface{}{"instance_type": "t3.micro"})
+	web1 := instance(nil, map[string]interface{}{"instance_type": "t3.micro"})
+	rso := &ResourcesOverview{
+		States: map[string]*StateOverview{
+			// Priced by Infracost, not by the price table
+			"aws_instance.web": {Type: ResourceTypeResource, Children: map[string]*StateOverview{
+				"aws_instance.web[0]": web0,
+				"aws_instance.web[1]": web1,
+			}},
+			"aws_instance.web[0]": web0,
+			"aws_instance.web[1]": web1,
+			// Missing from Infracost, priced by the price table
+			"aws_ebs_volume.data": instance(map[string]interface{}{"size": float64(100)}, map[string]interface{}{"size": float64(200)}),
+			// Usage based in Infracost and not in the price table
+			"aws_s3_bucket.logs": instance(nil, map[string]interface{}{"bucket": "logs"}),
+		},
+		Configs: map[string]*ConfigOverview{
+			"aws_instance.web":    resource("aws_instance"),
+			"aws_ebs_volume.data": resource("aws_ebs_volume"),
+			"aws_s3_bucket.logs":  resource("aws_s3_bucket"),
+		},
+	}
+
+	r.PopulateCosts(rso)
+
+	if rso.Currency != "EUR" {
+		t.Errorf("currency = %q, want the Infracost currency EUR", rso.Currency)
+	}
+
+	tests := []struct {
+		id   string
+		want *Cost
+	}{
+		{"aws_instance.web[0]", &Cost{Before: 10, After: 10, Delta: 0}},
+		{"aws_instance.web[1]", &Cost{Before: 0, After: 10, Delta: 10}},
+		{"aws_instance.web", &Cost{Before: 10, After: 20, Delta: 10}},
+		{"aws_ebs_volume.data", &Cost{Before: 8, After: 16, Delta: 8}},
+		{"aws_s3_bucket.logs", nil},
+	}
+
+	for _, tt := range tests {
+		if got := rso.States[tt.id].Cost; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s cost = %+v, want %+v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestRollupCost(t *testing.T) {
+	resource := func(before, after float64) *Resource {
+		return &Resource{Type: ResourceTypeResource, Cost: addCost(nil, &Cost{Before: before, After: after})}
+	}
+
+	web := resource(10.1, 20.2)
+	// Instances are children of the resource, and already summed into its cost
+	web.Children = map[string]*Resource{
+		"aws_instance.web[0]": resource(5.05, 10.1),
+		"aws_instance.web[1]": resource(5.05, 10.1),
+	}
+
+	root := &Resource{
+		Type: ResourceTypeModule,
+		Children: map[string]*Resource{
+			"main.tf": {Type: ResourceTypeFile, Children: map[string]*Resource{
+				"aws_instance.web": web,
+				"var.region":       {Type: ResourceTypeVariable},
+			}},
+			"db.tf": {Type: ResourceTypeFile, Children: map[string]*Resource{
+				"aws_db_instance.main": resource(30, 0),
+			}},
+			"network.tf": {Type: ResourceTypeFile, Children: map[string]*Resource{
+				"aws_vpc.main": {Type: ResourceTypeResource},
+			}},
+			"module.app": {Type: ResourceTypeModule, Children: map[string]*Resource{
+				"app.tf": {Type: ResourceTypeFile, Children: map[string]*Resource{
+					"module.app.aws_instance.api": resource(0, 4.2),
+				}},
+			}},
+		},
+	}
+
+	total := rollupCost(root)
+
+	if want := (&Cost{Before: 40.1, After: 24.4, Delta: -15.7}); !reflect.DeepEqual(total, want) || !reflect.DeepEqual(root.Cost, want) {
+		t.Errorf("root cost = %+v, want %+v", total, want)
+	}
+	if want := (&Cost{Before: 10.1, After: 20.2, Delta: 10.1}); !reflect.DeepEqual(root.Children["main.tf"].Cost, want) {
+		t.Errorf("main.tf cost = %+v, want %+v", root.Children["main.tf"].Cost, want)
+	}
+	if want := (&Cost{Before: 0, After: 4.2, Delta: 4.2}); !reflect.DeepEqual(root.Children["module.app"].Cost, want) {
+		t.Errorf("module.app cost = %+v, want %+v", root.Children["module.app"].Cost, want)
+	}
+	if cost := root.Children["network.tf"].Cost; cost != nil {
+		t.Errorf("network.tf cost = %+v, want none", cost)
+	}
+}
+
+func TestCostClasses(t *testing.T) {
+	tests := []struct {
+		cost *Cost
+		want []string
+	}{
+		{nil, nil},
+		{&Cost{Before: 10, After: 10, Delta: 0}, nil},
+		{&Cost{Before: 10, After: 10.004, Delta: 0.004}, nil},
+		{&Cost{Before: 10, After: 12, Delta: 2}, []string{"cost-increase"}},
+		{&Cost{Before: 12, After: 10, Delta: -2}, []string{"cost-decrease"}},
+	}
+
+	for _, tt := range tests {
+		if got := costClasses(tt.cost); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("costClasses(%+v) = %q, want %q", tt.cost, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeCost(t *testing.T) {
+	priced := func(before, after float64) *StateOverview {
+		return &StateOverview{Type: ResourceTypeResource, Cost: addCost(nil, &Cost{Before: before, After: after})}
+	}
+
+	web0, web1 := priced(10, 10), priced(0, 10.5)
+	rso := &ResourcesOverview{
+		Summary: &ChangeSummary{Add: 1},
+		States: map[string]*StateOverview{
+			// Summed from its instances, and not counted twice
+			"aws_instance.web":     {Type: ResourceTypeResource, Cost: addCost(addCost(nil, web0.Cost), web1.Cost), Children: map[string]*StateOverview{"aws_instance.web[0]": web0, "aws_instance.web[1]": web1}},
+			"aws_instance.web[0]":  web0,
+			"aws_instance.web[1]":  web1,
+			"aws_db_instance.main": priced(30, 0),
+			"aws_s3_bucket.logs":   {Type: ResourceTypeResource},
+			"module.app":           {Type: ResourceTypeModule, Children: map[string]*StateOverview{}},
+		},
+	}
+
+	summarizeCost(rso)
+
+	if rso.Summary.CostDelta == nil || *rso.Summary.CostDelta != -19.5 {
+		t.Errorf("cost delta = %v, want -19.5", rso.Summary.CostDelta)
+	}
+
+	// Without priced resources, there is no cost delta
+	unpriced := &ResourcesOverview{
+		Summary: &ChangeSummary{Add: 1},
+		States:  map[string]*StateOverview{"aws_s3_bucket.logs": {Type: ResourceTypeResource}},
+	}
+	summarizeCost(unpriced)
+	if unpriced.Summary.CostDelta != nil {
+		t.Errorf("cost delta = %v, want none", *unpriced.Summary.CostDelta)
+	}
+
+	// A state without a plan has no summary
+	summarizeCost(&ResourcesOverview{States: rso.States})
+}
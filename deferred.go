@@ -0,0 +1,137 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Reasons for deferring a resource change
+const (
+	DeferredUnknown               = "unknown"
+	DeferredInstanceCountUnknown  = "instance_count_unknown"
+	DeferredResourceConfigUnknown = "resource_config_unknown"
+	DeferredProviderConfigUnknown = "provider_config_unknown"
+	DeferredAbsentPrereq          = "absent_prereq"
+	DeferredDeferredPrereq        = "deferred_prereq"
+)
+
+// DeferredChange is a resource change that Terraform deferred to a later plan, e.g. because
+// the count or for_each of the resource is not known yet
+type DeferredChange struct {
+	Reason         string                 `json:"reason"`
+	ResourceChange *tfjson.ResourceChange `json:"resource_change"`
+}
+
+// PopulateDeferred adds the deferred changes of the plan to the state overview. Resources
+// and modules whose instances are not known yet get a placeholder instance, e.g. aws_instance.web[*].
+func (r *rover) PopulateDeferred(rso *ResourcesOverview) {
+	childIndex := regexp.MustCompile(`\[[^[\]]*\]$`)
+
+	rs := rso.States
+	rc := rso.Configs
+
+	for _, deferred := range r.Deferred {
+		resource := deferred.ResourceChange
+		if resource == nil || resource.Change == nil {
+			continue
+		}
+
+		id := resource.Address
+		configId := matchBrackets.ReplaceAllString(id, "")
+		parent := r.addDeferredModule(rso, resource.ModuleAddress)
+
+		resourceType := ResourceTypeResource
+		if resource.Mode == tfjson.DataResourceMode {
+			resourceType = ResourceTypeData
+		}
+
+		// Group the instance under its resource, like instances in state
+		if childIndex.MatchString(id) {
+			group := childIndex.ReplaceAllString(id, "")
+			if _, ok := rs[group]; !ok {
+				rs[group] = &StateOverview{
+					Type: resourceType,
+				}
+			}
+			if rs[group].Children == nil {
+				rs[group].Children = map[string]*StateOverview{}
+			}
+			rs[parent].Children[group] = rs[group]
+			parent = group
+		}
+
+		if _, ok := rs[id]; !ok {
+			rs[id] = &StateOverview{
+				Type: resourceType,
+			}
+		}
+		rs[parent].Children[id] = rs[id]
+
+		rs[id].Change = *resource.Change
+		rs[id].Deferred = deferred.Reason
+
+		if _, ok := rc[configId]; !ok {
+			rc[configId] = &ConfigOverview{
+				ResourceConfig: &tfjson.ConfigResource{
+					Name: resource.Name,
+					Type: resource.Type,
+				},
+			}
+		}
+	}
+}
+
+// addDeferredModule creates the state of a module instance of a deferred change if the plan
+// doesn't include it, and returns its ID
+func (r *rover) addDeferredModule(rso *ResourcesOverview, address string) string {
+	childIndex := regexp.MustCompile(`\[[^[\]]*\]$`)
+
+	rs := rso.States
+
+	id := ""
+	for _, call := range splitModuleAddress(address) {
+		parent := id
+		if id == "" {
+			id = call
+		} else {
+			id = strings.Join([]string{id, call}, ".")
+		}
+
+		if _, ok := rs[id]; ok {
+			continue
+		}
+
+		rs[id] = &StateOverview{
+			Type:     ResourceTypeModule,
+			Children: map[string]*StateOverview{},
+		}
+
+		// Module calls with count or for_each group their instances
+		if childIndex.MatchString(id) {
+			group := childIndex.ReplaceAllString(id, "")
+			if _, ok := rs[group]; !ok {
+				rs[group] = &StateOverview{
+					Type:     ResourceTypeModule,
+					IsParent: true,
+					Children: map[string]*StateOverview{},
+				}
+			}
+			rs[parent].Children[group] = rs[group]
+			rs[group].Children[id] = rs[id]
+		} else {
+			rs[parent].Children[id] = rs[id]
+		}
+	}
+
+	return id
+}
+
+// deferredClasses returns the graph node class of deferred resources
+func deferredClasses(re *Resource) []string {
+	if re.Deferred == "" {
+		return nil
+	}
+	return []string{"deferred"}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// getValidation reads the validate output from -validateJSONPath, or runs
+// terraform validate -json with -validate
+func (r *rover) getValidation() error {
+	if r.ValidatePath != "" {
+		log.Println("Using provided validate output...")
+
+		validateJSON, err := ioutil.ReadFile(r.ValidatePath)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to read validate output (%s): %s", r.ValidatePath, err))
+		}
+
+		r.Validation = &tfjson.ValidateOutput{}
+		if err := json.Unmarshal(validateJSON, r.Validation); err != nil {
+			return errors.New(fmt.Sprintf("Unable to read validate output (%s): %s", r.ValidatePath, err))
+		}
+		return nil
+	}
+
+	if !r.Validate {
+		return nil
+	}
+
+	log.Println("Validating configuration...")
+
+	tf, err := r.newTerraform(r.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	r.Validation, err = tf.Validate(context.Background())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to validate configuration: %s", err))
+	}
+
+	return nil
+}
+
+// configDiagnostics converts the diagnostics of loading a module with tfconfig. File names
+// are made relative to the working directory, like those of terraform validate.
+func (r *rover) configDiagnostics(module string, diags tfconfig.Diagnostics) []Diagnostic {
+	diagnostics := []Diagnostic{}
+
+	for _, diag := range diags {
+		d := Diagnostic{
+			Diagnostic: tfjson.Diagnostic{
+				Severity: tfjson.DiagnosticSeverityWarning,
+				Summary:  diag.Summary,
+				Detail:   diag.Detail,
+			},
+			Module: module,
+		}
+		if diag.Severity == tfconfig.DiagError {
+			d.Severity = tfjson.DiagnosticSeverityError
+		}
+
+		if diag.Pos != nil {
+			fname := diag.Pos.Filename
+			if abs, err := filepath.Abs(fname); err == nil {
+				if wd, err := filepath.Abs(r.WorkingDir); err == nil {
+					if rel, err := filepath.Rel(wd, abs); err == nil {
+						fname = rel
+					}
+				}
+			}
+			d.Range = &tfjson.Range{
+				Filename: fname,
+				Start:    tfjson.Pos{Line: diag.Pos.Line},
+				End:      tfjson.Pos{Line: diag.Pos.Line},
+			}
+		}
+
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics
+}
+
+// blockPosition is the position of a block of a module, e.g. a resource or a module call
+type blockPosition struct {
+	Address string
+	Pos     tfconfig.SourcePos
+}
+
+// moduleBlocks returns the positions of the resources, data sources, module calls,
+// variables and outputs of a module
+func moduleBlocks(module *tfconfig.Module) []blockPosition {
+	blocks := []blockPosition{}
+
+	for address, resource := range module.ManagedResources {
+		blocks = append(blocks, blockPosition{address, resource.Pos})
+	}
+	for address, resource := range module.DataResources {
+		blocks = append(blocks, blockPosition{address, resource.Pos})
+	}
+	for name, call := range module.ModuleCalls {
+		blocks = append(blocks, blockPosition{fmt.Sprintf("module.%s", name), call.Pos})
+	}
+	for name, variable := range module.Variables {
+		blocks = append(blocks, blockPosition{fmt.Sprintf("var.%s", name), variable.Pos})
+	}
+	for name, output := range module.Outputs {
+		blocks = append(blocks, blockPosition{fmt.Sprintf("output.%s", name), output.Pos})
+	}
+
+	return blocks
+}
+
+// blockAt returns the module of a file and the address of the block of the module at a
+// line, e.g. module.vpc and module.vpc.aws_subnet.private. The address is empty if the
+// module couldn't be loaded or no block starts before the line.
+func (r *rover) blockAt(rso *ResourcesOverview, fname string, line int) (string, string, bool) {
+	for id, config := range rso.Configs {
+		if config.Module == nil || (id != "" && config.ModuleConfig == nil) {
+			continue
+		}
+		if dir, err := filepath.Abs(config.Module.Path); err != nil || dir != filepath.Dir(fname) {
+			continue
+		}
+
+		// The block starting last before the line
+		address := ""
+		start := 0
+		for _, block := range moduleBlocks(config.Module) {
+			bname, err := filepath.Abs(block.Pos.Filename)
+			if err != nil || bname != fname || block.Pos.Line > line || block.Pos.Line <= start {
+				continue
+			}
+			start = block.Pos.Line
+			address = block.Address
+			if id != "" {
+				address = fmt.Sprintf("%s.%s", id, block.Address)
+			}
+		}
+		return id, address, true
+	}
+
+	// Modules that couldn't be loaded
+	for key, location := range rso.Locations {
+		if dir, err := filepath.Abs(location); err == nil && dir == filepath.Dir(fname) {
+			if key == "" {
+				return "", "", true
+			}
+			return fmt.Sprintf("module.%s", strings.ReplaceAll(key, ".", ".module.")), "", true
+		}
+	}
+
+	return "", "", false
+}
+
+// attributeDiagnostic finds the module and block a diagnostic refers to by its position
+func (r *rover) attributeDiagnostic(rso *ResourcesOverview, d *Diagnostic) {
+	if d.Range == nil || d.Address != "" {
+		return
+	}
+
+	fname, err := filepath.Abs(filepath.Join(r.WorkingDir, d.Range.Filename))
+	if err != nil {
+		return
+	}
+
+	if module, address, ok := r.blockAt(rso, fname, d.Range.Start.Line); ok {
+		d.Module = module
+		d.Address = address
+	}
+}
+
+// PopulateDiagnostics adds the diagnostics of loading each module and of terraform validate
+// to the overview, attributed to the module and block at their position
+func (r *rover) PopulateDiagnostics(rso *ResourcesOverview) {
+	ids := []string{}
+	for id := range rso.Configs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	diagnostics := []Diagnostic{}
+	for _, id := range ids {
+		diagnostics = append(diagnostics, rso.Configs[id].Diagnostics...)
+	}
+	if r.Validation != nil {
+		for _, diag := range r.Validation.Diagnostics {
+			diagnostics = append(diagnostics, Diagnostic{Diagnostic: diag})
+		}
+	}
+
+	// Parse errors are reported both by tfconfig and terraform validate, at the same
+	// position. Diagnostics without one, e.g. of modules that aren't loaded, are only
+	// duplicates for the same module and block.
+	seen := map[string]bool{}
+	for _, d := range diagnostics {
+		key := fmt.Sprintf("%s|%s|%s|%s", d.Summary, d.Detail, d.Module, d.Address)
+		if d.Range != nil {
+			key = fmt.Sprintf("%s|%s|%s|%s:%d:%d", d.Summary, d.Detail, d.Module, filepath.Clean(d.Range.Filename), d.Range.Start.Line, d.Range.Start.Column)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		r.attributeDiagnostic(rso, &d)
+		rso.Diagnostics = append(rso.Diagnostics, d)
+	}
+}
+
+// nodeDiagnostics returns the diagnostics of a resource, variable or output address, or
+// those of a module (or one of its files) that aren't attributed to a block
+func (r *rover) nodeDiagnostics(module string, address string, fname string) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, d := range r.RSO.Diagnostics {
+		switch {
+		case address != "":
+			if matchBrackets.ReplaceAllString(d.Address, "") != address {
+				continue
+			}
+		case d.Address != "" || d.Module != module:
+			continue
+		case fname != "" && (d.Range == nil || filepath.Base(d.Range.Filename) != fname):
+			continue
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	return diagnostics
+}
+
+// diagnosticClasses returns the graph node class of nodes with errors or warnings
+func diagnosticClasses(diagnostics []Diagnostic) []string {
+	var classes []string
+	for _, d := range diagnostics {
+		if d.Severity == tfjson.DiagnosticSeverityError {
+			return []string{"diagnostic-error"}
+		}
+		classes = []string{"diagnostic-warning"}
+	}
+	return classes
+}
+
+// moduleNotLoaded is the diagnostic of a module whose configuration couldn't be found
+func moduleNotLoaded(module string, source string) Diagnostic {
+	detail := fmt.Sprintf("%s has no location in .terraform/modules/modules.json", module)
+	if source != "" && moduleSourceType(source) != ModuleSourceLocal {
+		detail += fmt.Sprintf(" and its source (%s) isn't local", source)
+	}
+
+	return Diagnostic{
+		Diagnostic: tfjson.Diagnostic{
+			Severity: tfjson.DiagnosticSeverityWarning,
+			Summary:  "Module configuration not loaded",
+			Detail:   fmt.Sprintf("%s. Run terraform init to visualize its files.", detail),
+		},
+		Module: module,
+	}
+}
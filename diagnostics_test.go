@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func testDiagnostic(summary string, fname string, line int) tfjson.Diagnostic {
+	d := tfjson.Diagnostic{Severity: tfjson.DiagnosticSeverityError, Summary: summary}
+	if fname != "" {
+		d.Range = &tfjson.Range{Filename: fname, Start: tfjson.Pos{Line: line}}
+	}
+	return d
+}
+
+func TestPopulateDiagnosticsDuplicates(t *testing.T) {
+	r := &rover{
+		WorkingDir: t.TempDir(),
+		Validation: &tfjson.ValidateOutput{Diagnostics: []tfjson.Diagnostic{
+			testDiagnostic("Argument or block definition required", "main.tf", 3),
+			testDiagnostic("Argument or block definition required", "main.tf", 9),
+			testDiagnostic("Argument or block definition required", "./modules/app/main.tf", 3),
+			testDiagnostic("Unsupported argument", "main.tf", 3),
+		}},
+	}
+	rso := &ResourcesOverview{
+		Configs: map[string]*ConfigOverview{
+			"": {Diagnostics: []Diagnostic{
+				{Diagnostic: testDiagnostic("Argument or block definition required", "./main.tf", 3)},
+			}},
+			"module.app": {Diagnostics: []Diagnostic{moduleNotLoaded("module.app", "")}},
+			"module.db":  {Diagnostics: []Diagnostic{moduleNotLoaded("module.db", "")}},
+			"module.vpc": {Diagnostics: []Diagnostic{moduleNotLoaded("module.vpc", ""), moduleNotLoaded("module.vpc", "")}},
+		},
+	}
+
+	r.PopulateDiagnostics(rso)
+
+	// The parse error of main.tf line 3 is reported by tfconfig and validate
+	if len(rso.Diagnostics) != 7 {
+		t.Fatalf("diagnostics = %d, want 7: %+v", len(rso.Diagnostics), rso.Diagnostics)
+	}
+
+	modules := map[string]int{}
+	for _, d := range rso.Diagnostics {
+		if d.Summary == "Module configuration not loaded" {
+			modules[d.Module]++
+		}
+	}
+	for _, module := range []string{"module.app", "module.db", "module.vpc"} {
+		if modules[module] != 1 {
+			t.Errorf("%s module not loaded warnings = %d, want 1", module, modules[module])
+		}
+	}
+}
+
+func TestGenerateResourceOverviewDiagnostics(t *testing.T) {
+	// Spare capacity, appending to r.Diagnostics in place would overwrite it
+	diagnostics := make([]Diagnostic, 1, 8)
+	diagnostics[0] = Diagnostic{Diagnostic: testDiagnostic("Plan log error", "", 0)}
+
+	r := &rover{
+		WorkingDir:  t.TempDir(),
+		Diagnostics: diagnostics,
+		Validation: &tfjson.ValidateOutput{Diagnostics: []tfjson.Diagnostic{
+			testDiagnostic("Unsupported argument", "main.tf", 3),
+		}},
+		State: &tfjson.State{Values: &tfjson.StateValues{RootModule: &tfjson.StateModule{}}},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.GenerateResourceOverview(); err != nil {
+			t.Fatal(err)
+		}
+		if len(r.RSO.Diagnostics) != 2 {
+			t.Fatalf("diagnostics = %d, want 2: %+v", len(r.RSO.Diagnostics), r.RSO.Diagnostics)
+		}
+	}
+
+	if len(r.Diagnostics) != 1 || diagnostics[:2][1].Summary != "" {
+		t.Errorf("r.Diagnostics was modified: %+v", diagnostics[:cap(diagnostics)])
+	}
+}
+
+func TestPopulateDiagnosticsRangeKey(t *testing.T) {
+	at := func(summary string, detail string, module string, column int) Diagnostic {
+		d := Diagnostic{Diagnostic: testDiagnostic(summary, "main.tf", 3), Module: module}
+		d.Detail = detail
+		d.Range.Start.Column = column
+		return d
+	}
+
+	tests := []struct {
+		name        string
+		diagnostics []Diagnostic
+		want        int
+	}{
+		{"same position", []Diagnostic{at("Invalid reference", "", "", 5), at("Invalid reference", "", "", 5)}, 1},
+		{"other column", []Diagnostic{at("Invalid reference", "", "", 5), at("Invalid reference", "", "", 12)}, 2},
+		{"other detail", []Diagnostic{at("Invalid reference", "var.a", "", 5), at("Invalid reference", "var.b", "", 5)}, 2},
+		{"other module", []Diagnostic{at("Invalid reference", "", "module.app", 5), at("Invalid reference", "", "module.db", 5)}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &rover{WorkingDir: t.TempDir()}
+			rso := &ResourcesOverview{Configs: map[string]*ConfigOverview{"": {Diagnostics: tt.diagnostics}}}
+
+			r.PopulateDiagnostics(rso)
+			if len(rso.Diagnostics) != tt.want {
+				t.Errorf("diagnostics = %d, want %d: %+v", len(rso.Diagnostics), tt.want, rso.Diagnostics)
+			}
+		})
+	}
+}
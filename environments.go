@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Environment is one workspace or tfvars set of the same configuration
+type Environment struct {
+	Name        string
+	Workspace   string
+	TfVarsFiles []string
+	Plan        *tfjson.Plan
+}
+
+// EnvironmentMatrix compares the change action of every configuration address across environments
+type EnvironmentMatrix struct {
+	Environments []string                     `json:"environments"`
+	Resources    map[string]map[string]Action `json:"resources"`
+	// Resources whose change action differs between environments
+	Divergent []string `json:"divergent,omitempty"`
+}
+
+// actionPrecedence orders actions when the instances of a resource change differently
+var actionPrecedence = map[Action]int{
+	ActionNoop:    0,
+	ActionRead:    1,
+	ActionMove:    2,
+	ActionImport:  3,
+	ActionUpdate:  4,
+	ActionCreate:  5,
+	ActionForget:  6,
+	ActionDelete:  7,
+	ActionReplace: 8,
+}
+
+// ParseEnvironment parses an environment flag, name=file.tfvars
+func ParseEnvironment(value string) (*Environment, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.New(fmt.Sprintf("Invalid environment %s, expected name=file.tfvars", value))
+	}
+
+	return &Environment{
+		Name:        parts[0],
+		TfVarsFiles: []string{parts[1]},
+	}, nil
+}
+
+// NewEnvironmentMatrix returns the change action of each configuration address in each environment.
+// Moves and imports without other changes are found in the change details of the plans.
+func NewEnvironmentMatrix(envs []*Environment, details map[*tfjson.ResourceChange]*ChangeDetail) *EnvironmentMatrix {
+	matrix := &EnvironmentMatrix{
+		Environments: []string{},
+		Resources:    map[string]map[string]Action{},
+	}
+
+	for _, env := range envs {
+		matrix.Environments = append(matrix.Environments, env.Name)
+
+		for _, rc := range env.Plan.ResourceChanges {
+			address := matchBrackets.ReplaceAllString(rc.Address, "")
+
+			action := ActionNoop
+			if rc.Change != nil && len(rc.Change.Actions) > 0 {
+				action = Action(string(rc.Change.Actions[0]))
+				if len(rc.Change.Actions) > 1 {
+					action = ActionReplace
+				}
+			}
+			if detail, ok := details[rc]; ok && action == ActionNoop {
+				if detail.Importing != nil {
+					action = ActionImport
+				} else if detail.PreviousAddress != "" && detail.PreviousAddress != rc.Address {
+					action = ActionMove
+				}
+			}
+
+			if matrix.Resources[address] == nil {
+				matrix.Resources[address] = map[string]Action{}
+			}
+			if current, ok := matrix.Resources[address][env.Name]; !ok || actionPrecedence[action] > actionPrecedence[current] {
+				matrix.Resources[address][env.Name] = action
+			}
+		}
+	}
+
+	for address, actions := range matrix.Resources {
+		// A resource missing from an environment's plan doesn't exist there
+		for _, env := range matrix.Environments {
+			if _, ok := actions[env]; !ok {
+				actions[env] = ActionNoop
+			}
+		}
+
+		for _, env := range matrix.Environments {
+			if actions[env] != actions[matrix.Environments[0]] {
+				matrix.Divergent = append(matrix.Divergent, address)
+				break
+			}
+		}
+	}
+	sort.Strings(matrix.Divergent)
+
+	return matrix
+}
+
+// Highlight adds the divergent class to the nodes of divergent resources in every environment
+func (m *EnvironmentMatrix) Highlight(nodes []Node) {
+	for i, node := range nodes {
+		if node.Data.Type != ResourceTypeResource && node.Data.Type != ResourceTypeData {
+			continue
+		}
+
+		address := matchBrackets.ReplaceAllString(node.Data.ID, "")
+		for _, env := range m.Environments {
+			address = strings.TrimPrefix(address, fmt.Sprintf("module.%s.", stackUnitKey(env)))
+		}
+
+		if isStringInSlice(m.Divergent, address) {
+			nodes[i].Classes = fmt.Sprintf("%s divergent", node.Classes)
+		}
+	}
+}
+
+// getEnvironmentPlans plans every workspace, or every tfvars set, and combines them into a
+// single plan with one module per environment. The configuration is initialized once, and
+// the workspace selected before is selected again when done.
+func (r *rover) getEnvironmentPlans(tmpDir string) error {
+	tf, err := r.newTerraform(r.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	// Workspaces can only be listed once the backend is initialized
+	if err := r.initTerraform(tf); err != nil {
+		return err
+	}
+
+	workspaces, currentWorkspace, err := tf.WorkspaceList(context.Background())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to list workspaces: %s", err))
+	}
+
+	envs := r.Environments
+	if r.AllWorkspaces {
+		for _, ws := range workspaces {
+			envs = append(envs, &Environment{Name: ws, Workspace: ws})
+		}
+	}
+
+	defer func() {
+		log.Printf("Selecting %s workspace again...", currentWorkspace)
+		if err := tf.WorkspaceSelect(context.Background(), currentWorkspace); err != nil {
+			log.Printf("Unable to select workspace (%s): %s\n", currentWorkspace, err)
+		}
+	}()
+
+	baseTfVarsFiles := r.TfVarsFiles
+	baseWorkspace := r.WorkspaceName
+	defer func() {
+		r.TfVarsFiles = baseTfVarsFiles
+		r.WorkspaceName = baseWorkspace
+	}()
+
+	units := []*StackUnit{}
+	r.Units = map[string]string{}
+
+	for _, env := range envs {
+		log.Printf("Generating plan for environment %s...", env.Name)
+
+		r.TfVarsFiles = append(append([]string{}, baseTfVarsFiles...), env.TfVarsFiles...)
+		r.WorkspaceName = baseWorkspace
+		if env.Workspace != "" {
+			r.WorkspaceName = env.Workspace
+		}
+
+		workspace := currentWorkspace
+		if r.WorkspaceName != "" {
+			workspace = r.WorkspaceName
+		}
+		log.Printf("Running in %s workspace...", workspace)
+		if err := tf.WorkspaceSelect(context.Background(), workspace); err != nil {
+			return errors.New(fmt.Sprintf("Unable to select workspace (%s): %s", workspace, err))
+		}
+
+		key := stackUnitKey(env.Name)
+		env.Plan, err = r.planTerraform(tf, filepath.Join(tmpDir, fmt.Sprintf("%s.tfplan", key)))
+		if err != nil {
+			return errors.New(fmt.Sprintf("%s (environment %s)", err, env.Name))
+		}
+
+		units = append(units, &StackUnit{
+			Key:      key,
+			Dir:      r.WorkingDir,
+			Source:   "./",
+			Plan:     env.Plan,
+			Checks:   r.Checks,
+			Deferred: r.Deferred,
+		})
+		r.Units[key] = r.WorkingDir
+	}
+
+	// The matrix is keyed by the configuration address, so it's built before
+	// the plans are nested in the combined plan
+	r.EnvMatrix = NewEnvironmentMatrix(envs, r.ChangeDetails)
+	r.combineStackUnits(units)
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func testResourceChange(address string, actions ...tfjson.Action) *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: address,
+		Change:  &tfjson.Change{Actions: actions},
+	}
+}
+
+func TestNewEnvironmentMatrix(t *testing.T) {
+	imported := testResourceChange("aws_s3_bucket.logs", tfjson.ActionNoop)
+	moved := testResourceChange("aws_instance.web", tfjson.ActionNoop)
+
+	staging := &Environment{Name: "staging", Plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+		testResourceChange("aws_instance.web", tfjson.ActionNoop),
+		testResourceChange("aws_s3_bucket.logs", tfjson.ActionNoop),
+		testResourceChange("aws_db_instance.main[0]", tfjson.ActionNoop),
+		testResourceChange("aws_db_instance.main[1]", tfjson.ActionUpdate),
+		testResourceChange("aws_iam_role.ci", tfjson.Action(ActionForget)),
+	}}}
+	prod := &Environment{Name: "prod", Plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+		moved,
+		imported,
+		testResourceChange("aws_db_instance.main[0]", tfjson.ActionDelete, tfjson.ActionCreate),
+		testResourceChange("aws_iam_role.ci", tfjson.Action(ActionForget)),
+		testResourceChange("aws_sqs_queue.jobs", tfjson.ActionCreate),
+	}}}
+
+	details := map[*tfjson.ResourceChange]*ChangeDetail{
+		imported: {Importing: &Importing{ID: "logs"}},
+		moved:    {PreviousAddress: "aws_instance.app"},
+	}
+
+	matrix := NewEnvironmentMatrix([]*Environment{staging, prod}, details)
+
+	want := map[string]map[string]Action{
+		"aws_instance.web":     {"staging": ActionNoop, "prod": ActionMove},
+		"aws_s3_bucket.logs":   {"staging": ActionNoop, "prod": ActionImport},
+		"aws_db_instance.main": {"staging": ActionUpdate, "prod": ActionReplace},
+		"aws_iam_role.ci":      {"staging": ActionForget, "prod": ActionForget},
+		"aws_sqs_queue.jobs":   {"staging": ActionNoop, "prod": ActionCreate},
+	}
+	if !reflect.DeepEqual(matrix.Resources, want) {
+		t.Errorf("resources = %v, want %v", matrix.Resources, want)
+	}
+
+	divergent := []string{"aws_db_instance.main", "aws_instance.web", "aws_s3_bucket.logs", "aws_sqs_queue.jobs"}
+	if !reflect.DeepEqual(matrix.Divergent, divergent) {
+		t.Errorf("divergent = %q, want %q", matrix.Divergent, divergent)
+	}
+}
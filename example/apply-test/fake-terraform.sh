@@ -0,0 +1,40 @@
+#!/bin/sh
+# Stands in for the terraform binary to try out apply visualization without real infrastructure:
+#   rover -workingDir example/apply-test -tfPath example/apply-test/fake-terraform.sh -planPath plan.out -apply
+# Set FAKE_TF_DELAY to slow the apply down (seconds between events). TestApplyFakeTerraform
+# runs it with FAKE_TF_DELAY=0.
+
+DELAY=${FAKE_TF_DELAY:-2}
+
+case "$1" in
+version)
+  echo '{"terraform_version": "1.5.5", "platform": "linux_amd64", "provider_selections": {}}'
+  ;;
+show)
+  cat <<'JSON'
+{"format_version":"1.0","terraform_version":"1.5.5","planned_values":{"root_module":{"resources":[{"address":"random_pet.server[0]","mode":"managed","type":"random_pet","name":"server","index":0,"provider_name":"registry.terraform.io/hashicorp/random","schema_version":0,"values":{"length":2}},{"address":"random_pet.server[1]","mode":"managed","type":"random_pet","name":"server","index":1,"provider_name":"registry.terraform.io/hashicorp/random","schema_version":0,"values":{"length":2}},{"address":"random_id.token","mode":"managed","type":"random_id","name":"token","provider_name":"registry.terraform.io/hashicorp/random","schema_version":0,"values":{"byte_length":8}}]}},"resource_changes":[{"address":"random_pet.server[0]","mode":"managed","type":"random_pet","name":"server","index":0,"provider_name":"registry.terraform.io/hashicorp/random","change":{"actions":["create"],"before":null,"after":{"length":2},"after_unknown":{"id":true}}},{"address":"random_pet.server[1]","mode":"managed","type":"random_pet","name":"server","index":1,"provider_name":"registry.terraform.io/hashicorp/random","change":{"actions":["create"],"before":null,"after":{"length":2},"after_unknown":{"id":true}}},{"address":"random_id.token","mode":"managed","type":"random_id","name":"token","provider_name":"registry.terraform.io/hashicorp/random","change":{"actions":["create"],"before":null,"after":{"byte_length":8},"after_unknown":{"id":true}}}],"configuration":{"root_module":{"resources":[{"address":"random_pet.server","mode":"managed","type":"random_pet","name":"server","provider_config_key":"random","schema_version":0,"count_expression":{"constant_value":2}},{"address":"random_id.token","mode":"managed","type":"random_id","name":"token","provider_config_key":"random","expressions":{"byte_length":{"constant_value":8},"keepers":{"references":["random_pet.server[0].id","random_pet.server[0]","random_pet.server"]}},"schema_version":0}]}}}
+JSON
+  ;;
+apply)
+  hook() {
+    echo "{\"@level\":\"info\",\"@message\":\"$1: $2\",\"type\":\"$2\",\"hook\":{\"resource\":{\"addr\":\"$1\",\"module\":\"\",\"resource\":\"$1\",\"implied_provider\":\"random\",\"resource_type\":\"$3\",\"resource_name\":\"$4\",\"resource_key\":null},\"action\":\"create\",\"elapsed_seconds\":$5}}"
+  }
+  echo '{"@level":"info","@message":"Terraform 1.5.5","type":"version","terraform":"1.5.5","ui":"1.1"}'
+  hook 'random_pet.server[0]' apply_start random_pet server 0
+  hook 'random_pet.server[1]' apply_start random_pet server 0
+  sleep "$DELAY"
+  hook 'random_pet.server[0]' apply_progress random_pet server "$DELAY"
+  hook 'random_pet.server[0]' apply_complete random_pet server "$DELAY"
+  hook 'random_pet.server[1]' apply_errored random_pet server "$DELAY"
+  echo '{"@level":"error","@message":"Error: quota exceeded","type":"diagnostic","diagnostic":{"severity":"error","summary":"quota exceeded","detail":"","address":"random_pet.server[1]"}}'
+  hook 'random_id.token' apply_start random_id token 0
+  sleep "$DELAY"
+  hook 'random_id.token' apply_complete random_id token "$DELAY"
+  echo '{"@level":"info","@message":"Apply complete!","type":"change_summary","changes":{"add":2,"change":0,"remove":0,"operation":"apply"}}'
+  exit 1
+  ;;
+*)
+  echo "fake-terraform: unsupported command $1" >&2
+  exit 1
+  ;;
+esac
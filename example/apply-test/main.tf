@@ -0,0 +1,10 @@
+resource "random_pet" "server" {
+  count = 2
+}
+
+resource "random_id" "token" {
+  byte_length = 8
+  keepers = {
+    server = random_pet.server[0].id
+  }
+}
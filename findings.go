@@ -0,0 +1,502 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severities of findings, from highest to lowest
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+var severities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// Finding is an issue reported by a static analyzer (tflint, tfsec, trivy, checkov or
+// any SARIF producer), with the address of the block it was matched to
+type Finding struct {
+	Tool      string `json:"tool"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Link      string `json:"link,omitempty"`
+	Filename  string `json:"filename,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	Address   string `json:"address,omitempty"`
+}
+
+// FindingsSummary counts the findings by severity and tool
+type FindingsSummary struct {
+	Total      int            `json:"total"`
+	Severities map[string]int `json:"severities"`
+	Tools      map[string]int `json:"tools"`
+	Unmatched  int            `json:"unmatched"`
+	Findings   []*Finding     `json:"findings"`
+}
+
+// tflint --format json
+type tflintOutput struct {
+	Issues []struct {
+		Rule struct {
+			Name     string `json:"name"`
+			Severity string `json:"severity"`
+			Link     string `json:"link"`
+		} `json:"rule"`
+		Message string `json:"message"`
+		Range   struct {
+			Filename string `json:"filename"`
+			Start    struct {
+				Line int `json:"line"`
+			} `json:"start"`
+			End struct {
+				Line int `json:"line"`
+			} `json:"end"`
+		} `json:"range"`
+	} `json:"issues"`
+}
+
+// tfsec --format json
+type tfsecOutput struct {
+	Results []struct {
+		RuleID      string   `json:"rule_id"`
+		LongID      string   `json:"long_id"`
+		Description string   `json:"description"`
+		Severity    string   `json:"severity"`
+		Resource    string   `json:"resource"`
+		Links       []string `json:"links"`
+		Location    struct {
+			Filename  string `json:"filename"`
+			StartLine int    `json:"start_line"`
+			EndLine   int    `json:"end_line"`
+		} `json:"location"`
+	} `json:"results"`
+}
+
+// trivy config --format json
+type trivyOutput struct {
+	Results []struct {
+		Target            string `json:"Target"`
+		Misconfigurations []struct {
+			ID            string `json:"ID"`
+			Title         string `json:"Title"`
+			Message       string `json:"Message"`
+			Severity      string `json:"Severity"`
+			PrimaryURL    string `json:"PrimaryURL"`
+			Status        string `json:"Status"`
+			CauseMetadata struct {
+				Resource  string `json:"Resource"`
+				StartLine int    `json:"StartLine"`
+				EndLine   int    `json:"EndLine"`
+			} `json:"CauseMetadata"`
+		} `json:"Misconfigurations"`
+	} `json:"Results"`
+}
+
+// checkov -o json, for a single framework
+type checkovOutput struct {
+	CheckType string `json:"check_type"`
+	Results   struct {
+		FailedChecks []struct {
+			CheckID       string `json:"check_id"`
+			CheckName     string `json:"check_name"`
+			FilePath      string `json:"file_path"`
+			FileAbsPath   string `json:"file_abs_path"`
+			FileLineRange []int  `json:"file_line_range"`
+			Resource      string `json:"resource"`
+			Severity      string `json:"severity"`
+			Guideline     string `json:"guideline"`
+		} `json:"failed_checks"`
+	} `json:"results"`
+}
+
+// SARIF 2.1.0
+type sarifOutput struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					ID      string `json:"id"`
+					HelpURI string `json:"helpUri"`
+					Default struct {
+						Level string `json:"level"`
+					} `json:"defaultConfiguration"`
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Level   string `json:"level"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+						EndLine   int `json:"endLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// normalizeSeverity maps the severities and levels of each analyzer to critical, high,
+// medium, low or info
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return SeverityCritical
+	case "high", "error":
+		return SeverityHigh
+	case "medium", "warning":
+		return SeverityMedium
+	case "low", "notice", "note":
+		return SeverityLow
+	case "info", "none":
+		return SeverityInfo
+	}
+	// e.g. checkov checks without a severity
+	return SeverityMedium
+}
+
+// securitySeverity maps a SARIF security-severity score (CVSS) to a severity
+func securitySeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityInfo
+}
+
+// ReadFindings reads a tflint, tfsec, trivy or checkov JSON report, or a SARIF log,
+// detecting its format from its top-level keys
+func ReadFindings(path string) ([]*Finding, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read findings (%s): %s", path, err))
+	}
+
+	// checkov outputs a list when scanning several frameworks
+	if strings.HasPrefix(strings.TrimSpace(string(content)), "[") {
+		outputs := []checkovOutput{}
+		if err := json.Unmarshal(content, &outputs); err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to parse findings (%s): %s", path, err))
+		}
+		findings := []*Finding{}
+		for _, output := range outputs {
+			findings = append(findings, checkovFindings(output)...)
+		}
+		return findings, nil
+	}
+
+	keys := map[string]json.RawMessage{}
+	if err := json.Unmarshal(content, &keys); err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to parse findings (%s): %s", path, err))
+	}
+
+	var output interface{}
+	switch {
+	case keys["runs"] != nil:
+		output = &sarifOutput{}
+	case keys["issues"] != nil:
+		output = &tflintOutput{}
+	case keys["Results"] != nil:
+		output = &trivyOutput{}
+	case keys["check_type"] != nil:
+		output = &checkovOutput{}
+	case keys["results"] != nil:
+		output = &tfsecOutput{}
+	default:
+		return nil, errors.New(fmt.Sprintf("Unable to parse findings (%s): unknown format", path))
+	}
+
+	if err := json.Unmarshal(content, output); err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to parse findings (%s): %s", path, err))
+	}
+
+	switch o := output.(type) {
+	case *sarifOutput:
+		return sarifFindings(o), nil
+	case *tflintOutput:
+		return tflintFindings(o), nil
+	case *trivyOutput:
+		return trivyFindings(o), nil
+	case *checkovOutput:
+		return checkovFindings(*o), nil
+	case *tfsecOutput:
+		return tfsecFindings(o), nil
+	}
+	return nil, nil
+}
+
+func tflintFindings(output *tflintOutput) []*Finding {
+	findings := []*Finding{}
+	for _, issue := range output.Issues {
+		findings = append(findings, &Finding{
+			Tool:      "tflint",
+			Rule:      issue.Rule.Name,
+			Severity:  normalizeSeverity(issue.Rule.Severity),
+			Message:   issue.Message,
+			Link:      issue.Rule.Link,
+			Filename:  issue.Range.Filename,
+			StartLine: issue.Range.Start.Line,
+			EndLine:   issue.Range.End.Line,
+		})
+	}
+	return findings
+}
+
+func tfsecFindings(output *tfsecOutput) []*Finding {
+	findings := []*Finding{}
+	for _, result := range output.Results {
+		finding := &Finding{
+			Tool:      "tfsec",
+			Rule:      result.LongID,
+			Severity:  normalizeSeverity(result.Severity),
+			Message:   result.Description,
+			Filename:  result.Location.Filename,
+			StartLine: result.Location.StartLine,
+			EndLine:   result.Location.EndLine,
+			Resource:  result.Resource,
+		}
+		if finding.Rule == "" {
+			finding.Rule = result.RuleID
+		}
+		if len(result.Links) > 0 {
+			finding.Link = result.Links[0]
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func trivyFindings(output *trivyOutput) []*Finding {
+	findings := []*Finding{}
+	for _, result := range output.Results {
+		for _, m := range result.Misconfigurations {
+			if m.Status != "" && m.Status != "FAIL" {
+				continue
+			}
+			message := m.Message
+			if message == "" {
+				message = m.Title
+			}
+			findings = append(findings, &Finding{
+				Tool:      "trivy",
+				Rule:      m.ID,
+				Severity:  normalizeSeverity(m.Severity),
+				Message:   message,
+				Link:      m.PrimaryURL,
+				Filename:  result.Target,
+				StartLine: m.CauseMetadata.StartLine,
+				EndLine:   m.CauseMetadata.EndLine,
+				Resource:  m.CauseMetadata.Resource,
+			})
+		}
+	}
+	return findings
+}
+
+func checkovFindings(output checkovOutput) []*Finding {
+	findings := []*Finding{}
+	for _, check := range output.Results.FailedChecks {
+		finding := &Finding{
+			Tool:     "checkov",
+			Rule:     check.CheckID,
+			Severity: normalizeSeverity(check.Severity),
+			Message:  check.CheckName,
+			Link:     check.Guideline,
+			Filename: check.FileAbsPath,
+			Resource: check.Resource,
+		}
+		if finding.Filename == "" {
+			finding.Filename = check.FilePath
+		}
+		if len(check.FileLineRange) == 2 {
+			finding.StartLine = check.FileLineRange[0]
+			finding.EndLine = check.FileLineRange[1]
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+func sarifFindings(output *sarifOutput) []*Finding {
+	findings := []*Finding{}
+	for _, run := range output.Runs {
+		tool := strings.ToLower(run.Tool.Driver.Name)
+
+		for _, result := range run.Results {
+			finding := &Finding{
+				Tool:    tool,
+				Rule:    result.RuleID,
+				Message: result.Message.Text,
+			}
+
+			level := result.Level
+			for _, rule := range run.Tool.Driver.Rules {
+				if rule.ID != result.RuleID {
+					continue
+				}
+				finding.Link = rule.HelpURI
+				if level == "" {
+					level = rule.Default.Level
+				}
+				// Security analyzers rate rules with a CVSS score
+				if score, ok := rule.Properties["security-severity"].(string); ok {
+					if s, err := strconv.ParseFloat(score, 64); err == nil {
+						finding.Severity = securitySeverity(s)
+					}
+				}
+			}
+			if finding.Severity == "" {
+				if level == "" {
+					level = "warning"
+				}
+				finding.Severity = normalizeSeverity(level)
+			}
+
+			if len(result.Locations) > 0 {
+				location := result.Locations[0].PhysicalLocation
+				finding.Filename = location.ArtifactLocation.URI
+				finding.StartLine = location.Region.StartLine
+				finding.EndLine = location.Region.EndLine
+			}
+
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// findingFile returns the absolute path of the file of a finding. Analyzers report paths
+// relative to the scanned directory (checkov with a leading /), or absolute paths.
+func (r *rover) findingFile(fname string) (string, error) {
+	fname = strings.TrimPrefix(fname, "file://")
+	if filepath.IsAbs(fname) {
+		if _, err := os.Stat(fname); err == nil {
+			return fname, nil
+		}
+	}
+	return filepath.Abs(filepath.Join(r.WorkingDir, fname))
+}
+
+// GenerateFindings reads the reports of -findings and matches each finding to the block at
+// its position, or to the resource it names
+func (r *rover) GenerateFindings() error {
+	if len(r.FindingPaths) == 0 {
+		return nil
+	}
+
+	log.Println("Matching findings...")
+
+	summary := &FindingsSummary{
+		Severities: map[string]int{},
+		Tools:      map[string]int{},
+		Findings:   []*Finding{},
+	}
+
+	for _, path := range r.FindingPaths {
+		findings, err := ReadFindings(path)
+		if err != nil {
+			return err
+		}
+
+		for _, finding := range findings {
+			if finding.Filename != "" && finding.StartLine > 0 {
+				if fname, err := r.findingFile(finding.Filename); err == nil {
+					_, finding.Address, _ = r.blockAt(r.RSO, fname, finding.StartLine)
+				}
+			}
+			if finding.Address == "" && finding.Resource != "" {
+				configId := matchBrackets.ReplaceAllString(finding.Resource, "")
+				if config, ok := r.RSO.Configs[configId]; ok && config.ResourceConfig != nil {
+					finding.Address = configId
+				}
+			}
+
+			summary.Total++
+			summary.Severities[finding.Severity]++
+			summary.Tools[finding.Tool]++
+			if finding.Address == "" {
+				summary.Unmatched++
+			}
+			summary.Findings = append(summary.Findings, finding)
+		}
+	}
+
+	sort.SliceStable(summary.Findings, func(i, j int) bool {
+		return severityRank(summary.Findings[i].Severity) < severityRank(summary.Findings[j].Severity)
+	})
+
+	r.Findings = summary
+	r.RSO.Findings = summary.Findings
+
+	return nil
+}
+
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(severities)
+}
+
+// nodeFindings returns the findings matched to an address
+func (r *rover) nodeFindings(address string) []*Finding {
+	if r.Findings == nil {
+		return nil
+	}
+
+	var findings []*Finding
+	for _, finding := range r.Findings.Findings {
+		if finding.Address == address {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// findingClasses returns the graph node class of the highest severity of a node's findings,
+// e.g. finding-high
+func findingClasses(findings []*Finding) []string {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	highest := findings[0].Severity
+	for _, finding := range findings {
+		if severityRank(finding.Severity) < severityRank(highest) {
+			highest = finding.Severity
+		}
+	}
+	return []string{fmt.Sprintf("finding-%s", highest)}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		report   string
+		wantErr  string
+		findings []Finding
+	}{
+		{
+			name: "tflint",
+			report: `{"issues": [{"rule": {"name": "terraform_unused_declarations", "severity": "warning", "link": "https://example.com/unused"},
+				"message": "variable \"x\" is declared but not used", "range": {"filename": "variables.tf", "start": {"line": 1}, "end": {"line": 3}}}], "errors": []}`,
+			findings: []Finding{
+				{Tool: "tflint", Rule: "terraform_unused_declarations", Severity: SeverityMedium, Message: `variable "x" is declared but not used`,
+					Link: "https://example.com/unused", Filename: "variables.tf", StartLine: 1, EndLine: 3},
+			},
+		},
+		{
+			name: "tfsec",
+			report: `{"results": [
+				{"rule_id": "AVD-AWS-0086", "long_id": "aws-s3-block-public-acls", "description": "No public access block", "severity": "HIGH",
+				 "resource": "aws_s3_bucket.logs", "links": ["https://example.com/s3", "https://example.com/other"], "location": {"filename": "/src/main.tf", "start_line": 5, "end_line": 9}},
+				{"rule_id": "AVD-AWS-0089", "description": "Bucket logging", "severity": "LOW", "resource": "aws_s3_bucket.logs", "location": {"filename": "/src/main.tf", "start_line": 5, "end_line": 9}}]}`,
+			findings: []Finding{
+				{Tool: "tfsec", Rule: "aws-s3-block-public-acls", Severity: SeverityHigh, Message: "No public access block", Link: "https://example.com/s3",
+					Filename: "/src/main.tf", StartLine: 5, EndLine: 9, Resource: "aws_s3_bucket.logs"},
+				{Tool: "tfsec", Rule: "AVD-AWS-0089", Severity: SeverityLow, Message: "Bucket logging", Filename: "/src/main.tf", StartLine: 5, EndLine: 9, Resource: "aws_s3_bucket.logs"},
+			},
+		},
+		{
+			name: "trivy",
+			report: `{"SchemaVersion": 2, "Results": [{"Target": "main.tf", "Misconfigurations": [
+				{"ID": "AVD-AWS-0107", "Title": "Ingress from the internet", "Message": "Security group rule allows ingress from public internet.", "Severity": "CRITICAL",
+				 "PrimaryURL": "https://example.com/sg", "Status": "FAIL", "CauseMetadata": {"Resource": "aws_security_group.web", "StartLine": 12, "EndLine": 20}},
+				{"ID": "AVD-AWS-0124", "Title": "Missing description", "Severity": "LOW", "Status": "PASS"},
+				{"ID": "AVD-AWS-0099", "Title": "Missing description", "Severity": "LOW", "CauseMetadata": {"StartLine": 12, "EndLine": 20}}]}]}`,
+			findings: []Finding{
+				{Tool: "trivy", Rule: "AVD-AWS-0107", Severity: SeverityCritical, Message: "Security group rule allows ingress from public internet.",
+					Link: "https://example.com/sg", Filename: "main.tf", StartLine: 12, EndLine: 20, Resource: "aws_security_group.web"},
+				{Tool: "trivy", Rule: "AVD-AWS-0099", Severity: SeverityLow, Message: "Missing description", Filename: "main.tf", StartLine: 12, EndLine: 20},
+			},
+		},
+		{
+			name: "checkov",
+			report: `{"check_type": "terraform", "results": {"failed_checks": [
+				{"check_id": "CKV_AWS_18", "check_name": "Ensure the S3 bucket has access logging enabled", "file_path": "/main.tf", "file_abs_path": "/src/main.tf",
+				 "file_line_range": [5, 9], "resource": "aws_s3_bucket.logs", "severity": null, "guideline": "https://example.com/ckv18"}]}}`,
+			findings: []Finding{
+				{Tool: "checkov", Rule: "CKV_AWS_18", Severity: SeverityMedium, Message: "Ensure the S3 bucket has access logging enabled",
+					Link: "https://example.com/ckv18", Filename: "/src/main.tf", StartLine: 5, EndLine: 9, Resource: "aws_s3_bucket.logs"},
+			},
+		},
+		{
+			name: "checkov frameworks",
+			report: `[{"check_type": "terraform", "results": {"failed_checks": [
+				{"check_id": "CKV_AWS_20", "check_name": "S3 bucket ACL", "file_path": "/main.tf", "file_line_range": [5, 9], "resource": "aws_s3_bucket.logs", "severity": "HIGH"}]}},
+				{"check_type": "secrets", "results": {"failed_checks": [
+				{"check_id": "CKV_SECRET_2", "check_name": "AWS Access Key", "file_path": "/providers.tf", "file_line_range": [3], "severity": "LOW"}]}}]`,
+			findings: []Finding{
+				{Tool: "checkov", Rule: "CKV_AWS_20", Severity: SeverityHigh, Message: "S3 bucket ACL", Filename: "/main.tf", StartLine: 5, EndLine: 9, Resource: "aws_s3_bucket.logs"},
+				{Tool: "checkov", Rule: "CKV_SECRET_2", Severity: SeverityLow, Message: "AWS Access Key", Filename: "/providers.tf"},
+			},
+		},
+		{
+			name: "sarif",
+			report: `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "Trivy", "rules": [
+				{"id": "AVD-AWS-0086", "helpUri": "https://example.com/s3", "defaultConfiguration": {"level": "error"}, "properties": {"security-severity": "8.1"}},
+				{"id": "AVD-AWS-0089", "helpUri": "https://example.com/log", "defaultConfiguration": {"level": "note"}}]}},
+				"results": [
+				{"ruleId": "AVD-AWS-0086", "level": "error", "message": {"text": "No public access block"},
+				 "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.tf"}, "region": {"startLine": 5, "endLine": 9}}}]},
+				{"ruleId": "AVD-AWS-0089", "message": {"text": "Bucket logging"}},
+				{"ruleId": "custom", "message": {"text": "No rule"}}]}]}`,
+			findings: []Finding{
+				{Tool: "trivy", Rule: "AVD-AWS-0086", Severity: SeverityHigh, Message: "No public access block", Link: "https://example.com/s3", Filename: "main.tf", StartLine: 5, EndLine: 9},
+				{Tool: "trivy", Rule: "AVD-AWS-0089", Severity: SeverityLow, Message: "Bucket logging", Link: "https://example.com/log"},
+				{Tool: "trivy", Rule: "custom", Severity: SeverityMedium, Message: "No rule"},
+			},
+		},
+		{
+			name:    "unknown format",
+			report:  `{"format_version": "1.2", "resource_changes": []}`,
+			wantErr: "unknown format",
+		},
+		{
+			name:    "not JSON",
+			report:  `main.tf:1: warning`,
+			wantErr: "Unable to parse findings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "findings.json")
+			if err := os.WriteFile(path, []byte(tt.report), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			findings, err := ReadFindings(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadFindings() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadFindings() error = %v", err)
+			}
+
+			got := []Finding{}
+			for _, finding := range findings {
+				got = append(got, *finding)
+			}
+			if !reflect.DeepEqual(got, tt.findings) {
+				t.Errorf("ReadFindings() = %+v, want %+v", got, tt.findings)
+			}
+		})
+	}
+}
+
+func TestReadFindingsMissingFile(t *testing.T) {
+	if _, err := ReadFindings(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "Unable to read findings") {
+		t.Errorf("ReadFindings() error = %v, want Unable to read findings", err)
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"log"
-	"regexp"
 	"strings"
 
 	tfjson "github.com/hashicorp/terraform-json"
@@ -18,6 +17,7 @@ const (
 	FNAME_BG_COLOR  string = "white"
 	RESOURCE_COLOR  string = "lightgray"
 	LOCAL_COLOR     string = "black"
+	CHECK_COLOR     string = "#17a2b8"
 )
 
 // ModuleGraph TODO
@@ -40,6 +40,39 @@ type NodeData struct {
 	Parent      string       `json:"parent,omitempty"`
 	ParentColor string       `json:"parentColor,omitempty"`
 	Change      string       `json:"change,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Lifecycle   *Lifecycle   `json:"lifecycle,omitempty"`
+	// Variable
+	Value        interface{} `json:"value,omitempty"`
+	VariableType string      `json:"variable_type,omitempty"`
+	Default      interface{} `json:"default,omitempty"`
+	Validations  []Condition `json:"validations,omitempty"`
+	// Output
+	Before       interface{} `json:"before,omitempty"`
+	After        interface{} `json:"after,omitempty"`
+	AfterUnknown bool        `json:"after_unknown,omitempty"`
+	// Moved resource
+	PreviousAddress string `json:"previous_address,omitempty"`
+	// Check block
+	Assertions []Condition `json:"assertions,omitempty"`
+	Check      *CheckState `json:"check,omitempty"`
+	// Reason the change of a resource was deferred
+	Deferred string `json:"deferred,omitempty"`
+	// Documentation of the resource type
+	DocsURL string `json:"docs_url,omitempty"`
+	// Provider
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Why a resource is replaced
+	Replacement *Replacement `json:"replacement,omitempty"`
+	// Configuration and validate diagnostics
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// Static analyzer findings
+	Findings []*Finding `json:"findings,omitempty"`
+	// Monthly cost
+	Cost *Cost `json:"cost,omitempty"`
+	// Tagging and naming conventions the resource doesn't follow
+	Violations []*Violation `json:"violations,omitempty"`
 }
 
 // Edge TODO
@@ -80,6 +113,11 @@ func (r *rover) GenerateGraph() error {
 		}
 	}
 
+	// Highlight resources that change differently between environments
+	if r.EnvMatrix != nil {
+		r.EnvMatrix.Highlight(nodes)
+	}
+
 	r.Graph = Graph{
 		Nodes: nodes,
 		Edges: edges,
@@ -133,12 +171,26 @@ func (r *rover) addNodes(base string, parent string, nodeMap map[string]Node, re
 					Type:        re.Type,
 					Parent:      midParent,
 					ParentColor: getResourceColor(nodeMap[parent].Data.Type),
+					DocsURL:     re.DocsURL,
 				},
 				Classes: fmt.Sprintf("%s-type", re.Type),
 			}
 
 			mrChange := string(re.ChangeAction)
 
+			classes := []string{fmt.Sprintf("%s-name", re.Type)}
+			if mrChange != "" {
+				classes = append(classes, mrChange)
+			}
+			classes = append(classes, changeClasses(re)...)
+			classes = append(classes, lifecycleClasses(re.Lifecycle)...)
+			classes = append(classes, checkClasses(re.Check)...)
+			classes = append(classes, deferredClasses(re)...)
+			classes = append(classes, diagnosticClasses(re.Diagnostics)...)
+			classes = append(classes, findingClasses(re.Findings)...)
+			classes = append(classes, costClasses(re.Cost)...)
+			classes = append(classes, violationClasses(re.Violations)...)
+
 			// Append resource name
 			nmo = append(nmo, id)
 			nodeMap[id] = Node{
@@ -149,8 +201,18 @@ func (r *rover) addNodes(base string, parent string, nodeMap map[string]Node, re
 					Parent:      mid,
 					ParentColor: getResourceColor(nodeMap[parent].Data.Type),
 					Change:      mrChange,
+					Lifecycle:   re.Lifecycle,
+					Check:       re.Check,
+					Deferred:    re.Deferred,
+					Replacement: re.Replacement,
+					Diagnostics: re.Diagnostics,
+					Findings:    re.Findings,
+					Cost:        re.Cost,
+					Violations:  re.Violations,
+
+					PreviousAddress: re.PreviousAddress,
 				},
-				Classes: fmt.Sprintf("%s-name %s", re.Type, mrChange),
+				Classes: strings.Join(classes, " "),
 			}
 			//fmt.Printf(id + " - " + mid + "\n")
 
@@ -162,6 +224,10 @@ func (r *rover) addNodes(base string, parent string, nodeMap map[string]Node, re
 				fid = fmt.Sprintf("%s.%s", parent, fid)
 			}
 			//fmt.Printf("%v\n", fid)
+			classes := []string{getResourceClass(re.Type)}
+			classes = append(classes, diagnosticClasses(re.Diagnostics)...)
+			classes = append(classes, costClasses(re.Cost)...)
+
 			nmo = append(nmo, fid)
 			nodeMap[fid] = Node{
 				Data: NodeData{
@@ -170,9 +236,11 @@ func (r *rover) addNodes(base string, parent string, nodeMap map[string]Node, re
 					Type:        re.Type,
 					Parent:      parent,
 					ParentColor: getResourceColor(nodeMap[parent].Data.Type),
+					Diagnostics: re.Diagnostics,
+					Cost:        re.Cost,
 				},
 
-				Classes: getResourceClass(re.Type),
+				Classes: strings.Join(classes, " "),
 			}
 			nmo = append(nmo, r.addNodes(base, fid, nodeMap, re.Children)...)
 		} else {
@@ -189,17 +257,48 @@ func (r *rover) addNodes(base string, parent string, nodeMap map[string]Node, re
 
 			//fmt.Printf("%v - %v\n", id, re.Type)
 
+			classes := []string{getResourceClass(re.Type)}
+			classes = append(classes, checkClasses(re.Check)...)
+			classes = append(classes, diagnosticClasses(re.Diagnostics)...)
+			classes = append(classes, findingClasses(re.Findings)...)
+			classes = append(classes, costClasses(re.Cost)...)
+			if re.Type == ResourceTypeProvider && re.Conflict {
+				classes = append(classes, "provider-conflict")
+			}
+
 			nmo = append(nmo, id)
 			nodeMap[id] = Node{
 				Data: NodeData{
-					ID:          id,
-					Label:       label,
-					Type:        re.Type,
-					Parent:      parent,
-					ParentColor: getResourceColor(nodeMap[pid].Data.Type),
+					ID:           id,
+					Label:        label,
+					Type:         re.Type,
+					Parent:       parent,
+					ParentColor:  getResourceColor(nodeMap[pid].Data.Type),
+					Change:       string(re.ChangeAction),
+					Description:  re.Description,
+					Value:        re.Value,
+					VariableType: re.VariableType,
+					Default:      re.Default,
+					Validations:  re.Validations,
+					Before:       re.Before,
+					After:        re.After,
+					AfterUnknown: re.AfterUnknown,
+					Assertions:   re.Assertions,
+					Check:        re.Check,
+					Diagnostics:  re.Diagnostics,
+					Findings:     re.Findings,
+					Cost:         re.Cost,
 				},
 
-				Classes: getResourceClass(re.Type),
+				Classes: strings.Join(classes, " "),
+			}
+
+			if re.Type == ResourceTypeProvider {
+				node := nodeMap[id]
+				node.Data.Label = strings.TrimPrefix(re.Source, fmt.Sprintf("%s/", DefaultRegistry))
+				node.Data.Source = re.Source
+				node.Data.Version = re.Version
+				nodeMap[id] = node
 			}
 
 			nmo = append(nmo, r.addNodes(base, id, nodeMap, re.Children)...)
@@ -248,8 +347,6 @@ func (r *rover) GenerateNodes() []Node {
 func (r *rover) addEdges(base string, parent string, edgeMap map[string]Edge, resources map[string]*Resource) []string {
 	emo := []string{}
 	for id, re := range resources {
-		matchBrackets := regexp.MustCompile(`\[[^\[\]]*\]`)
-
 		configId := matchBrackets.ReplaceAllString(id, "")
 
 		var expressions map[string]*tfjson.Expression
@@ -265,6 +362,9 @@ func (r *rover) addEdges(base string, parent string, edgeMap map[string]Edge, re
 			} else if r.RSO.Configs[configId].OutputConfig != nil {
 				expressions = make(map[string]*tfjson.Expression)
 				expressions["output"] = r.RSO.Configs[configId].OutputConfig.Expression
+				// If Check
+			} else if r.RSO.Configs[configId].CheckConfig != nil {
+				expressions = checkExpressions(r.RSO.Configs[configId].CheckConfig)
 			}
 		}
 		// fmt.Printf("%+v - %+v\n", oName, oValue)
@@ -339,6 +439,7 @@ func (r *rover) GenerateEdges() []Edge {
 	//config := r.Plan.Config.RootModule
 
 	emo = append(emo, r.addEdges("", "", edgeMap, r.Map.Root)...)
+	emo = append(emo, r.addProviderEdges(edgeMap, r.resourceProviders(), r.Map.Root)...)
 
 	edges := make([]Edge, 0, len(edgeMap))
 	exists := make(map[string]bool)
@@ -365,6 +466,8 @@ func getResourceColor(t ResourceType) string {
 		return VARIABLE_COLOR
 	case ResourceTypeLocal:
 		return LOCAL_COLOR
+	case ResourceTypeCheck:
+		return CHECK_COLOR
 	}
 	return RESOURCE_COLOR
 }
@@ -397,6 +500,10 @@ func getResourceClass(resourceType ResourceType) string {
 		return "locals"
 	case ResourceTypeModule:
 		return "module"
+	case ResourceTypeCheck:
+		return "check"
+	case ResourceTypeProvider:
+		return "provider"
 	}
 	return "resource-type"
 }
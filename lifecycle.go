@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Lifecycle is the lifecycle block of a resource
+type Lifecycle struct {
+	PreventDestroy      bool     `json:"prevent_destroy,omitempty"`
+	CreateBeforeDestroy bool     `json:"create_before_destroy,omitempty"`
+	IgnoreChanges       []string `json:"ignore_changes,omitempty"`
+	IgnoreAllChanges    bool     `json:"ignore_all_changes,omitempty"`
+	ReplaceTriggeredBy  []string `json:"replace_triggered_by,omitempty"`
+	// Preconditions and postconditions
+	Preconditions  []Condition `json:"preconditions,omitempty"`
+	Postconditions []Condition `json:"postconditions,omitempty"`
+}
+
+var resourcesSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+	},
+}
+
+var resourceSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "lifecycle"},
+	},
+}
+
+var lifecycleSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "precondition"},
+		{Type: "postcondition"},
+	},
+	Attributes: []hcl.AttributeSchema{
+		{Name: "prevent_destroy"},
+		{Name: "create_before_destroy"},
+		{Name: "ignore_changes"},
+		{Name: "replace_triggered_by"},
+	},
+}
+
+// ResourceLifecycles returns the lifecycle blocks of the managed resources declared in dir,
+// keyed by resource address. tfconfig and the plan don't include them.
+func ResourceLifecycles(dir string) map[string]*Lifecycle {
+	lifecycles := map[string]*Lifecycle{}
+
+	parser := hclparse.NewParser()
+
+	for _, fname := range configFiles(dir) {
+		file, src, err := parseConfigFile(parser, fname)
+		if err != nil {
+			continue
+		}
+
+		content, _, _ := file.Body.PartialContent(resourcesSchema)
+		for _, block := range content.Blocks {
+			resource, _, _ := block.Body.PartialContent(resourceSchema)
+
+			for _, lifecycleBlock := range resource.Blocks {
+				attrs, _, _ := lifecycleBlock.Body.PartialContent(lifecycleSchema)
+				lifecycle := &Lifecycle{}
+
+				if attr, ok := attrs.Attributes["prevent_destroy"]; ok {
+					lifecycle.PreventDestroy = boolAttribute(attr)
+				}
+
+				if attr, ok := attrs.Attributes["create_before_destroy"]; ok {
+					lifecycle.CreateBeforeDestroy = boolAttribute(attr)
+				}
+
+				if attr, ok := attrs.Attributes["ignore_changes"]; ok {
+					// ignore_changes = all, or "all" in JSON syntax
+					if hcl.ExprAsKeyword(attr.Expr) == "all" || expressionSource(attr.Expr, src) == "all" {
+						lifecycle.IgnoreAllChanges = true
+					} else {
+						lifecycle.IgnoreChanges = listAttribute(attr, src)
+					}
+				}
+
+				if attr, ok := attrs.Attributes["replace_triggered_by"]; ok {
+					lifecycle.ReplaceTriggeredBy = listAttribute(attr, src)
+				}
+
+				for _, conditionBlock := range attrs.Blocks {
+					condition, _, ok := parseCondition(conditionBlock, src)
+					if !ok {
+						continue
+					}
+					if conditionBlock.Type == "precondition" {
+						lifecycle.Preconditions = append(lifecycle.Preconditions, condition)
+					} else {
+						lifecycle.Postconditions = append(lifecycle.Postconditions, condition)
+					}
+				}
+
+				lifecycles[fmt.Sprintf("%s.%s", block.Labels[0], block.Labels[1])] = lifecycle
+			}
+		}
+	}
+
+	return lifecycles
+}
+
+func boolAttribute(attr *hcl.Attribute) bool {
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !val.IsKnown() || val.IsNull() {
+		return false
+	}
+	return val.True()
+}
+
+// listAttribute returns the source of each element of a list of references
+func listAttribute(attr *hcl.Attribute, src []byte) []string {
+	exprs, diags := hcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		return nil
+	}
+
+	elements := []string{}
+	for _, expr := range exprs {
+		elements = append(elements, expressionSource(expr, src))
+	}
+	return elements
+}
+
+// Lifecycle settings that resources can be filtered by
+const (
+	LifecyclePreventDestroy      = "prevent_destroy"
+	LifecycleCreateBeforeDestroy = "create_before_destroy"
+	LifecycleIgnoreChanges       = "ignore_changes"
+	LifecycleReplaceTriggeredBy  = "replace_triggered_by"
+)
+
+var lifecycleSettings = []string{LifecyclePreventDestroy, LifecycleCreateBeforeDestroy, LifecycleIgnoreChanges, LifecycleReplaceTriggeredBy}
+
+// Settings returns the lifecycle settings set in the block
+func (lifecycle *Lifecycle) Settings() []string {
+	settings := []string{}
+	if lifecycle == nil {
+		return settings
+	}
+
+	if lifecycle.PreventDestroy {
+		settings = append(settings, LifecyclePreventDestroy)
+	}
+	if lifecycle.CreateBeforeDestroy {
+		settings = append(settings, LifecycleCreateBeforeDestroy)
+	}
+	if lifecycle.IgnoreAllChanges || len(lifecycle.IgnoreChanges) > 0 {
+		settings = append(settings, LifecycleIgnoreChanges)
+	}
+	if len(lifecycle.ReplaceTriggeredBy) > 0 {
+		settings = append(settings, LifecycleReplaceTriggeredBy)
+	}
+	return settings
+}
+
+// lifecycleClasses returns graph node classes for lifecycle settings, e.g. prevent-destroy,
+// so resources can be filtered by them
+func lifecycleClasses(lifecycle *Lifecycle) []string {
+	classes := []string{}
+	for _, setting := range lifecycle.Settings() {
+		classes = append(classes, strings.ReplaceAll(setting, "_", "-"))
+	}
+	return classes
+}
+
+// LifecycleResources returns the lifecycle blocks of the resources that have all the
+// settings, or any setting if none are given, keyed by configuration address
+func (r *rover) LifecycleResources(settings []string) (map[string]*Lifecycle, error) {
+	for _, setting := range settings {
+		if !isStringInSlice(lifecycleSettings, setting) {
+			return nil, errors.New(fmt.Sprintf("Unknown lifecycle setting %s, expected one of %s", setting, strings.Join(lifecycleSettings, ", ")))
+		}
+	}
+
+	resources := map[string]*Lifecycle{}
+	for id, config := range r.RSO.Configs {
+		if config.Lifecycle == nil {
+			continue
+		}
+
+		has := config.Lifecycle.Settings()
+		if len(has) == 0 {
+			continue
+		}
+
+		matches := true
+		for _, setting := range settings {
+			if !isStringInSlice(has, setting) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			resources[id] = config.Lifecycle
+		}
+	}
+
+	return resources, nil
+}
+
+// PopulateLifecycles adds the lifecycle block of each resource to its configuration. Child
+// modules that couldn't be loaded are read from their location in modules.json, if any.
+func (r *rover) PopulateLifecycles(rso *ResourcesOverview) {
+	for moduleId, dir := range moduleDirs(rso) {
+		if dir == "" {
+			log.Printf("Unable to read lifecycle blocks of %s, its source isn't available locally...\n", moduleId)
+			continue
+		}
+
+		prefix := moduleId
+		if prefix != "" {
+			prefix = fmt.Sprintf("%s.", prefix)
+		}
+
+		for address, lifecycle := range ResourceLifecycles(dir) {
+			if rc, ok := rso.Configs[fmt.Sprintf("%s%s", prefix, address)]; ok {
+				rc.Lifecycle = lifecycle
+			}
+		}
+	}
+}
+
+// isCritical checks if a resource was marked critical with -criticalResource, by type or address
+func (r *rover) isCritical(resourceType string, configId string) bool {
+	for _, critical := range r.Critical {
+		if critical != "" && (critical == resourceType || critical == configId) {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleDiagnostics warns about protected or critical resources that the plan deletes or replaces
+func (r *rover) LifecycleDiagnostics(rso *ResourcesOverview) []Diagnostic {
+	diagnostics := []Diagnostic{}
+
+	if r.Plan == nil {
+		return diagnostics
+	}
+
+	for _, rc := range r.Plan.ResourceChanges {
+		if rc.Change == nil || !rc.Change.Actions.Delete() && !rc.Change.Actions.Replace() {
+			continue
+		}
+
+		configId := matchBrackets.ReplaceAllString(rc.Address, "")
+		action, verb := "destroyed", "destroy"
+		if rc.Change.Actions.Replace() {
+			action, verb = "replaced", "replace"
+		}
+
+		var lifecycle *Lifecycle
+		if config, ok := rso.Configs[configId]; ok {
+			lifecycle = config.Lifecycle
+		}
+
+		if lifecycle != nil && lifecycle.PreventDestroy {
+			diagnostics = append(diagnostics, Diagnostic{
+				Diagnostic: tfjson.Diagnostic{
+					Severity: tfjson.DiagnosticSeverityWarning,
+					Summary:  fmt.Sprintf("Protected resource will be %s", action),
+					Detail:   fmt.Sprintf("%s has prevent_destroy set, but the plan will %s it.", rc.Address, verb),
+				},
+				Address: rc.Address,
+			})
+		} else if r.isCritical(rc.Type, configId) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Diagnostic: tfjson.Diagnostic{
+					Severity: tfjson.DiagnosticSeverityWarning,
+					Summary:  fmt.Sprintf("Critical resource will be %s", action),
+					Detail:   fmt.Sprintf("%s is marked critical, and the plan will %s it.", rc.Address, verb),
+				},
+				Address: rc.Address,
+			})
+		}
+	}
+
+	return diagnostics
+}
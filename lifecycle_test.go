@@ -0,0 +1,184 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestLifecycleResources(t *testing.T) {
+	r := &rover{
+		RSO: &ResourcesOverview{
+			Configs: map[string]*ConfigOverview{
+				"aws_db_instance.main":  {Lifecycle: &Lifecycle{PreventDestroy: true, IgnoreChanges: []string{"password"}}},
+				"aws_instance.web":      {Lifecycle: &Lifecycle{CreateBeforeDestroy: true}},
+				"module.app.aws_s3.log": {Lifecycle: &Lifecycle{PreventDestroy: true}},
+				"aws_iam_role.ci":       {Lifecycle: &Lifecycle{Preconditions: []Condition{{Condition: "true"}}}},
+				"aws_vpc.main":          {},
+			},
+		},
+	}
+
+	tests := []struct {
+		settings []string
+		want     []string
+		wantErr  bool
+	}{
+		{nil, []string{"aws_db_instance.main", "aws_instance.web", "module.app.aws_s3.log"}, false},
+		{[]string{LifecyclePreventDestroy}, []string{"aws_db_instance.main", "module.app.aws_s3.log"}, false},
+		{[]string{LifecyclePreventDestroy, LifecycleIgnoreChanges}, []string{"aws_db_instance.main"}, false},
+		{[]string{LifecycleReplaceTriggeredBy}, []string{}, false},
+		{[]string{"prevent-destroy"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		resources, err := r.LifecycleResources(tt.settings)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LifecycleResources(%q) error = nil, want an error", tt.settings)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("LifecycleResources(%q) error = %v", tt.settings, err)
+		}
+
+		got := []string{}
+		for id := range resources {
+			got = append(got, id)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LifecycleResources(%q) = %q, want %q", tt.settings, got, tt.want)
+		}
+	}
+}
+
+func TestLifecycleClasses(t *testing.T) {
+	tests := []struct {
+		lifecycle *Lifecycle
+		want      []string
+	}{
+		{nil, []string{}},
+		{&Lifecycle{}, []string{}},
+		{&Lifecycle{PreventDestroy: true, IgnoreAllChanges: true}, []string{"prevent-destroy", "ignore-changes"}},
+		{&Lifecycle{CreateBeforeDestroy: true, ReplaceTriggeredBy: []string{"null_resource.x"}}, []string{"create-before-destroy", "replace-triggered-by"}},
+	}
+
+	for _, tt := range tests {
+		if got := lifecycleClasses(tt.lifecycle); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lifecycleClasses(%+v) = %q, want %q", tt.lifecycle, got, tt.want)
+		}
+	}
+}
+
+func TestResourceLifecycles(t *testing.T) {
+	dir := t.TempDir()
+	native := `resource "aws_db_instance" "main" {
+  lifecycle {
+    prevent_destroy = true
+    ignore_changes  = [password, tags["owner"]]
+
+    precondition {
+      condition     = var.env == "prod"
+      error_message = "Only in prod."
+    }
+  }
+}
+
+resource "aws_instance" "web" {
+  lifecycle {
+    ignore_changes = all
+  }
+}
+`
+	jsonConfig := `{
+  "resource": {
+    "aws_instance": {
+      "api": {
+        "ami": "ami-123",
+        "lifecycle": {
+          "create_before_destroy": true,
+          "ignore_changes": ["tags"],
+          "replace_triggered_by": ["null_resource.deploy.id"],
+          "postcondition": {
+            "condition": "${self.ami != \"\"}",
+            "error_message": "AMI required."
+          }
+        }
+      },
+      "worker": {
+        "lifecycle": {"ignore_changes": "all"}
+      }
+    }
+  }
+}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(native), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "cdk.tf.json"), []byte(jsonConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*Lifecycle{
+		"aws_db_instance.main": {
+			PreventDestroy: true,
+			IgnoreChanges:  []string{"password", `tags["owner"]`},
+			Preconditions:  []Condition{{Condition: `var.env == "prod"`, ErrorMessage: "Only in prod."}},
+		},
+		"aws_instance.web": {IgnoreAllChanges: true},
+		"aws_instance.api": {
+			CreateBeforeDestroy: true,
+			IgnoreChanges:       []string{"tags"},
+			ReplaceTriggeredBy:  []string{"null_resource.deploy.id"},
+			Postconditions:      []Condition{{Condition: `self.ami != ""`, ErrorMessage: "AMI required."}},
+		},
+		"aws_instance.worker": {IgnoreAllChanges: true},
+	}
+
+	got := ResourceLifecycles(dir)
+	if !reflect.DeepEqual(got, want) {
+		for address, lifecycle := range got {
+			t.Logf("%s: %+v", address, lifecycle)
+		}
+		t.Errorf("ResourceLifecycles() didn't match")
+	}
+}
+
+func TestPopulateLifecycles(t *testing.T) {
+	dir := t.TempDir()
+	config := `resource "aws_s3_bucket" "logs" {
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// module.app.module.storage couldn't be loaded with tfconfig, but is in modules.json
+	rso := &ResourcesOverview{
+		Locations: map[string]string{"app.storage": dir},
+		Configs: map[string]*ConfigOverview{
+			"module.app.module.storage":                    {ModuleConfig: &tfjson.ModuleCall{}},
+			"module.app.module.storage.aws_s3_bucket.logs": {},
+			"module.remote":                                {ModuleConfig: &tfjson.ModuleCall{}},
+			"module.remote.aws_s3_bucket.logs":             {},
+		},
+	}
+
+	r := &rover{}
+	r.PopulateLifecycles(rso)
+
+	if lifecycle := rso.Configs["module.app.module.storage.aws_s3_bucket.logs"].Lifecycle; lifecycle == nil || !lifecycle.PreventDestroy {
+		t.Errorf("expected prevent_destroy from the module location, got %+v", lifecycle)
+	}
+	if lifecycle := rso.Configs["module.remote.aws_s3_bucket.logs"].Lifecycle; lifecycle != nil {
+		t.Errorf("expected no lifecycle for a module without a location, got %+v", lifecycle)
+	}
+}
@@ -12,7 +12,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -48,46 +50,135 @@ type rover struct {
 	Name             string
 	WorkingDir       string
 	TfPath           string
+	TerragruntPath   string
+	CDKTFDir         string
 	TfVarsFiles      []string
 	TfVars           []string
 	TfBackendConfigs []string
+	PlanTargets      []string
+	PlanReplaces     []string
+	PlanMode         string
+	PlanNoRefresh    bool
+	Parallelism      int
+	LockTimeout      string
+	SkipInit         bool
+	NoUpgrade        bool
+	PluginCacheDir   string
 	PlanPath         string
 	PlanJSONPath     string
+	PlanLogPath      string
+	UnitPlanJSON     string
+	StatePath        string
+	PriorStatePath   string
 	WorkspaceName    string
 	TFCOrgName       string
 	TFCWorkspaceName string
+	ApplyLogPath     string
+	ApplyPlanPath    string
 	ShowSensitive    bool
 	GenImage         bool
 	TFCNewRun        bool
+	ApplyRun         bool
 	Plan             *tfjson.Plan
+	State            *tfjson.State
+	ResourceDrift    []*tfjson.ResourceChange
+	PlanHasDrift     bool
+	Diagnostics      []Diagnostic
+	ChangeSummary    *ChangeSummary
 	RSO              *ResourcesOverview
 	Map              *Map
 	Graph            Graph
+	Apply            *ApplyWatcher
+	Tool             string
+	ToolVersion      string
+	Units            map[string]string
+	ChangeDetails    map[*tfjson.ResourceChange]*ChangeDetail
+	Checks           []*CheckResult
+	Deferred         []*DeferredChange
+	FetchSchema      bool
+	SchemaPath       string
+	Schemas          *tfjson.ProviderSchemas
+	Providers        []*Provider
+	Validate         bool
+	ValidatePath     string
+	Validation       *tfjson.ValidateOutput
+	FindingPaths     []string
+	Findings         *FindingsSummary
+	InfracostPath    string
+	Infracost        *InfracostOutput
+	PriceTablePath   string
+	Prices           *PriceTable
+	RulesPath        string
+	Rules            *ComplianceRules
+	Compliance       *ComplianceReport
+	ModuleManifest   map[string]ModuleLocation
+	Modules          *ModuleTree
+	Environments     []*Environment
+	AllWorkspaces    bool
+	Critical         []string
+	EnvMatrix        *EnvironmentMatrix
+	ConstructPaths   map[string]string
 }
 
 func main() {
-	var tfPath, workingDir, name, zipFileName, ipPort, planPath, planJSONPath, workspaceName, tfcOrgName, tfcWorkspaceName string
-	var standalone, genImage, showSensitive, getVersion, tfcNewRun bool
-	var tfVarsFiles, tfVars, tfBackendConfigs arrayFlags
-	flag.StringVar(&tfPath, "tfPath", "/bin/terraform", "Path to Terraform binary")
+	var tfPath, terragruntPath, terragruntPlanJSON, cdktfDir, workingDir, name, zipFileName, ipPort, planPath, planJSONPath, planLogPath, statePath, workspaceName, tfcOrgName, tfcWorkspaceName, applyLogPath, lockTimeout, pluginCacheDir, providerSchemaPath, validateJSONPath, infracostJSON, priceTable, complianceRules string
+	var standalone, genImage, showSensitive, getVersion, tfcNewRun, applyRun, destroy, refreshOnly, refresh, skipInit, noUpgrade, allWorkspaces, providerSchema, validate bool
+	var parallelism int
+	var tfVarsFiles, tfVars, tfBackendConfigs, targets, replaces, environments, criticalResources, findings arrayFlags
+	flag.StringVar(&tfPath, "tfPath", "", "Path to Terraform or OpenTofu binary (default terraform or tofu on PATH)")
+	flag.StringVar(&terragruntPath, "terragruntPath", "terragrunt", "Path to Terragrunt binary")
+	flag.StringVar(&terragruntPlanJSON, "terragruntPlanJSON", "", "Plan JSON file name in each Terragrunt unit, instead of running terragrunt plan")
+	flag.StringVar(&cdktfDir, "cdktfDir", "", "Path to CDK for Terraform output directory (cdktf.out)")
 	flag.StringVar(&workingDir, "workingDir", ".", "Path to Terraform configuration")
 	flag.StringVar(&name, "name", "rover", "Configuration name")
 	flag.StringVar(&zipFileName, "zipFileName", "rover", "Standalone zip file name")
 	flag.StringVar(&ipPort, "ipPort", "0.0.0.0:9000", "IP and port for Rover server")
 	flag.StringVar(&planPath, "planPath", "", "Plan file path")
 	flag.StringVar(&planJSONPath, "planJSONPath", "", "Plan JSON file path")
+	flag.StringVar(&planLogPath, "planLogPath", "", "Plan log (terraform plan -json) file path")
+	flag.StringVar(&statePath, "statePath", "", "State file (*.tfstate or terraform show -json) path, visualizes current state without a plan")
 	flag.StringVar(&workspaceName, "workspaceName", "", "Workspace name")
+	flag.BoolVar(&allWorkspaces, "allWorkspaces", false, "Plan every workspace and compare them")
+	flag.Var(&environments, "environment", "Environment to plan and compare (name=file.tfvars)")
 	flag.StringVar(&tfcOrgName, "tfcOrg", "", "Terraform Cloud Organization name")
 	flag.StringVar(&tfcWorkspaceName, "tfcWorkspace", "", "Terraform Cloud Workspace name")
 	flag.BoolVar(&standalone, "standalone", false, "Generate standalone HTML files")
 	flag.BoolVar(&showSensitive, "showSensitive", false, "Display sensitive values")
 	flag.BoolVar(&tfcNewRun, "tfcNewRun", false, "Create new Terraform Cloud run")
+	flag.BoolVar(&applyRun, "apply", false, "Apply the plan and visualize its progress")
+	flag.StringVar(&applyLogPath, "applyLogPath", "", "Follow apply log (terraform apply -json) file path")
 	flag.BoolVar(&getVersion, "version", false, "Get current version")
 	flag.BoolVar(&genImage, "genImage", false, "Generate graph image")
 	flag.Var(&tfVarsFiles, "tfVarsFile", "Path to *.tfvars files")
 	flag.Var(&tfVars, "tfVar", "Terraform variable (key=value)")
 	flag.Var(&tfBackendConfigs, "tfBackendConfig", "Path to *.tfbackend files")
-	flag.Parse()
+	flag.Var(&criticalResources, "criticalResource", "Resource type or address to warn about when deleted or replaced")
+	flag.Var(&targets, "target", "Resource address to target in plan")
+	flag.Var(&replaces, "replace", "Resource address to replace in plan")
+	flag.BoolVar(&destroy, "destroy", false, "Create a destroy plan")
+	flag.BoolVar(&refreshOnly, "refreshOnly", false, "Create a refresh-only plan")
+	flag.BoolVar(&refresh, "refresh", true, "Refresh state before planning")
+	flag.IntVar(&parallelism, "parallelism", 0, "Number of concurrent operations during plan")
+	flag.StringVar(&lockTimeout, "lockTimeout", "", "Duration to retry a state lock (e.g. 60s)")
+	flag.BoolVar(&skipInit, "skipInit", false, "Skip terraform init, for already initialized configurations")
+	flag.BoolVar(&noUpgrade, "noUpgrade", false, "Run terraform init without upgrading modules and providers")
+	flag.StringVar(&pluginCacheDir, "pluginCacheDir", "", "Provider plugin cache directory (TF_PLUGIN_CACHE_DIR)")
+	flag.BoolVar(&providerSchema, "providerSchema", false, "Annotate resource attributes with terraform providers schema -json")
+	flag.StringVar(&providerSchemaPath, "providerSchemaPath", "", "Provider schemas (terraform providers schema -json) file path")
+	flag.BoolVar(&validate, "validate", false, "Report terraform validate -json diagnostics")
+	flag.StringVar(&validateJSONPath, "validateJSONPath", "", "Validate output (terraform validate -json) file path")
+	flag.StringVar(&infracostJSON, "infracostJSON", "", "Infracost breakdown or diff (--format json) file path")
+	flag.StringVar(&priceTable, "priceTable", "", "Price table (JSON) file path, to estimate costs offline")
+	flag.StringVar(&complianceRules, "complianceRules", "", "Tagging and naming compliance rules (JSON) file path")
+	flag.Var(&findings, "findings", "tflint, tfsec, trivy or checkov JSON report, or SARIF log, to annotate resources with")
+
+	// rover state-diff [flags] old.tfstate new.tfstate
+	args := os.Args[1:]
+	stateDiff := len(args) > 0 && args[0] == "state-diff"
+	if stateDiff {
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(args)
 
 	if getVersion {
 		fmt.Printf("Rover v%s\n", VERSION)
@@ -99,6 +190,34 @@ func main() {
 	parsedTfVarsFiles := strings.Split(tfVarsFiles.String(), ",")
 	parsedTfVars := strings.Split(tfVars.String(), ",")
 	parsedTfBackendConfigs := strings.Split(tfBackendConfigs.String(), ",")
+	parsedTargets := strings.Split(targets.String(), ",")
+	parsedReplaces := strings.Split(replaces.String(), ",")
+	parsedCriticalResources := strings.Split(criticalResources.String(), ",")
+
+	if destroy && refreshOnly {
+		log.Fatal(errors.New("-destroy and -refreshOnly can't be used together"))
+	}
+
+	var parsedEnvironments []*Environment
+	for _, e := range environments {
+		env, err := ParseEnvironment(e)
+		if err != nil {
+			log.Fatal(err)
+		}
+		parsedEnvironments = append(parsedEnvironments, env)
+	}
+
+	if (allWorkspaces || len(parsedEnvironments) > 0) && applyRun {
+		log.Fatal(errors.New("Apply visualization is not available when comparing environments"))
+	}
+
+	// Left empty for plans rover doesn't generate, see inferPlanMode
+	planMode := ""
+	if destroy {
+		planMode = PlanModeDestroy
+	} else if refreshOnly {
+		planMode = PlanModeRefreshOnly
+	}
 
 	path, err := os.Getwd()
 	if err != nil {
@@ -117,21 +236,122 @@ func main() {
 		}
 	}
 
+	if planLogPath != "" {
+		if !strings.HasPrefix(planLogPath, "/") {
+			planLogPath = filepath.Join(path, planLogPath)
+		}
+	}
+
+	var priorStatePath string
+	if stateDiff {
+		if flag.NArg() != 2 {
+			log.Fatal(errors.New("Usage: rover state-diff [flags] old.tfstate new.tfstate"))
+		}
+		priorStatePath = flag.Arg(0)
+		statePath = flag.Arg(1)
+
+		if !strings.HasPrefix(priorStatePath, "/") {
+			priorStatePath = filepath.Join(path, priorStatePath)
+		}
+	}
+
+	if statePath != "" {
+		if !strings.HasPrefix(statePath, "/") {
+			statePath = filepath.Join(path, statePath)
+		}
+	}
+
+	if providerSchemaPath != "" {
+		if !strings.HasPrefix(providerSchemaPath, "/") {
+			providerSchemaPath = filepath.Join(path, providerSchemaPath)
+		}
+	}
+
+	if validateJSONPath != "" {
+		if !strings.HasPrefix(validateJSONPath, "/") {
+			validateJSONPath = filepath.Join(path, validateJSONPath)
+		}
+	}
+
+	if infracostJSON != "" {
+		if !strings.HasPrefix(infracostJSON, "/") {
+			infracostJSON = filepath.Join(path, infracostJSON)
+		}
+	}
+
+	if priceTable != "" {
+		if !strings.HasPrefix(priceTable, "/") {
+			priceTable = filepath.Join(path, priceTable)
+		}
+	}
+
+	if complianceRules != "" {
+		if !strings.HasPrefix(complianceRules, "/") {
+			complianceRules = filepath.Join(path, complianceRules)
+		}
+	}
+
+	var findingPaths []string
+	for _, f := range findings {
+		if !strings.HasPrefix(f, "/") {
+			f = filepath.Join(path, f)
+		}
+		findingPaths = append(findingPaths, f)
+	}
+
+	if applyLogPath != "" {
+		if !strings.HasPrefix(applyLogPath, "/") {
+			applyLogPath = filepath.Join(path, applyLogPath)
+		}
+	}
+
+	if (applyRun || applyLogPath != "") && (standalone || genImage) {
+		log.Fatal(errors.New("Apply visualization is not available in standalone or image generation mode"))
+	}
+
 	r := rover{
 		Name:             name,
 		WorkingDir:       workingDir,
 		TfPath:           tfPath,
+		TerragruntPath:   terragruntPath,
+		UnitPlanJSON:     terragruntPlanJSON,
+		CDKTFDir:         cdktfDir,
 		PlanPath:         planPath,
 		PlanJSONPath:     planJSONPath,
+		PlanLogPath:      planLogPath,
+		StatePath:        statePath,
+		PriorStatePath:   priorStatePath,
 		ShowSensitive:    showSensitive,
 		GenImage:         genImage,
 		TfVarsFiles:      parsedTfVarsFiles,
 		TfVars:           parsedTfVars,
 		TfBackendConfigs: parsedTfBackendConfigs,
+		PlanTargets:      parsedTargets,
+		PlanReplaces:     parsedReplaces,
+		PlanMode:         planMode,
+		PlanNoRefresh:    !refresh,
+		Parallelism:      parallelism,
+		LockTimeout:      lockTimeout,
+		SkipInit:         skipInit,
+		NoUpgrade:        noUpgrade,
+		PluginCacheDir:   pluginCacheDir,
+		FetchSchema:      providerSchema,
+		SchemaPath:       providerSchemaPath,
+		Validate:         validate,
+		ValidatePath:     validateJSONPath,
+		FindingPaths:     findingPaths,
+		InfracostPath:    infracostJSON,
+		PriceTablePath:   priceTable,
+		RulesPath:        complianceRules,
+		Environments:     parsedEnvironments,
+		AllWorkspaces:    allWorkspaces,
+		Critical:         parsedCriticalResources,
 		WorkspaceName:    workspaceName,
 		TFCOrgName:       tfcOrgName,
 		TFCWorkspaceName: tfcWorkspaceName,
 		TFCNewRun:        tfcNewRun,
+		ApplyRun:         applyRun,
+		ApplyLogPath:     applyLogPath,
 	}
 
 	// Generate assets
@@ -165,6 +385,13 @@ func main() {
 		return
 	}
 
+	if r.ApplyRun || r.ApplyLogPath != "" {
+		err = r.startApply()
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
 	err = r.startServer(ipPort, frontendFS)
 	if err != nil {
 		// http.Serve() returns error on shutdown
@@ -178,10 +405,51 @@ func main() {
 }
 
 func (r *rover) generateAssets() error {
-	// Get Plan
-	err := r.getPlan()
+	var err error
+
+	if r.PriorStatePath != "" {
+		// Compare States
+		err = r.getStateDiff()
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to compare States: %s", err))
+		}
+	} else if r.StatePath != "" {
+		// Get State
+		err = r.getState()
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to parse State: %s", err))
+		}
+	} else {
+		// Get Plan
+		err = r.getPlan()
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to parse Plan: %s", err))
+		}
+	}
+
+	err = r.getProviderSchemas()
+	if err != nil {
+		return err
+	}
+
+	err = r.getValidation()
+	if err != nil {
+		return err
+	}
+
+	err = r.getInfracost()
+	if err != nil {
+		return err
+	}
+
+	err = r.getPriceTable()
 	if err != nil {
-		return errors.New(fmt.Sprintf("Unable to parse Plan: %s", err))
+		return err
+	}
+
+	err = r.getComplianceRules()
+	if err != nil {
+		return err
 	}
 
 	// Generate RSO, Map, Graph
@@ -190,6 +458,21 @@ func (r *rover) generateAssets() error {
 		return err
 	}
 
+	err = r.GenerateProviderInventory()
+	if err != nil {
+		return err
+	}
+
+	err = r.GenerateModuleTree()
+	if err != nil {
+		return err
+	}
+
+	err = r.GenerateFindings()
+	if err != nil {
+		return err
+	}
+
 	err = r.GenerateMap()
 	if err != nil {
 		return err
@@ -208,20 +491,27 @@ func (r *rover) getPlan() error {
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tmpDir)
-
-	tf, err := tfexec.NewTerraform(r.WorkingDir, r.TfPath)
-	if err != nil {
-		return err
-	}
+	// The generated plan file is kept if it will be applied
+	defer func() {
+		if !strings.HasPrefix(r.ApplyPlanPath, tmpDir) {
+			os.RemoveAll(tmpDir)
+		}
+	}()
 
 	// If user provided path to plan file
 	if r.PlanPath != "" {
 		log.Println("Using provided plan...")
-		r.Plan, err = tf.ShowPlanFile(context.Background(), r.PlanPath)
+		tf, err := r.newTerraform(r.WorkingDir)
+		if err != nil {
+			return err
+		}
+		r.Plan, err = r.showPlanFile(tf, r.PlanPath)
 		if err != nil {
 			return errors.New(fmt.Sprintf("Unable to read Plan (%s): %s", r.PlanPath, err))
 		}
+		if r.ApplyRun {
+			r.ApplyPlanPath = r.PlanPath
+		}
 		return nil
 	}
 
@@ -240,13 +530,19 @@ func (r *rover) getPlan() error {
 			return errors.New(fmt.Sprintf("Unable to read Plan (%s): %s", r.PlanJSONPath, err))
 		}
 
-		if err := json.Unmarshal(planJson, &r.Plan); err != nil {
+		r.Plan, err = r.readPlanJSON(planJson)
+		if err != nil {
 			return errors.New(fmt.Sprintf("Unable to read Plan (%s): %s", r.PlanJSONPath, err))
 		}
 
 		return nil
 	}
 
+	// If user provided path to plan log (terraform plan -json output)
+	if r.PlanLogPath != "" {
+		return r.getPlanFromLog()
+	}
+
 	// If user specified TFC workspace
 	if r.TFCWorkspaceName != "" {
 		tfcToken := os.Getenv("TFC_TOKEN")
@@ -341,73 +637,254 @@ func (r *rover) getPlan() error {
 			return errors.New(fmt.Sprintf("Empty plan. Check run %s in %s in %s is not pending", run.ID, r.TFCWorkspaceName, r.TFCOrgName))
 		}
 
-		if err := json.Unmarshal(planBytes, &r.Plan); err != nil {
+		r.Plan, err = r.readPlanJSON(planBytes)
+		if err != nil {
 			return errors.New(fmt.Sprintf("Unable to parse plan (ID: %s) from %s in %s organization.: %s", planID, r.TFCWorkspaceName, r.TFCOrgName, err))
 		}
 
 		return nil
 	}
 
-	log.Println("Initializing Terraform...")
+	// If user specified workspaces or tfvars sets to compare
+	if r.AllWorkspaces || len(r.Environments) > 0 {
+		return r.getEnvironmentPlans(tmpDir)
+	}
 
-	// Create TF Init options
-	var tfInitOptions []tfexec.InitOption
-	tfInitOptions = append(tfInitOptions, tfexec.Upgrade(true))
+	// If user provided CDKTF output directory, or working directory is a CDKTF project
+	if r.CDKTFDir != "" {
+		return r.getCDKTFPlan(r.CDKTFDir, tmpDir)
+	}
+	if outDir := r.findCDKTFOutDir(); outDir != "" {
+		return r.getCDKTFPlan(outDir, tmpDir)
+	}
 
-	// Add *.tfbackend files
-	for _, tfBackendConfig := range r.TfBackendConfigs {
-		if tfBackendConfig != "" {
-			tfInitOptions = append(tfInitOptions, tfexec.BackendConfig(tfBackendConfig))
-		}
+	// If working directory is managed by Terragrunt
+	if r.isTerragrunt() {
+		return r.getTerragruntPlan(tmpDir)
+	}
+
+	tf, err := r.newTerraform(r.WorkingDir)
+	if err != nil {
+		return err
 	}
 
-	// tfInitOptions = append(tfInitOptions, tfexec.LockTimeout("60s"))
+	planPath := fmt.Sprintf("%s/%s-%v", tmpDir, "roverplan", time.Now().Unix())
 
-	err = tf.Init(context.Background(), tfInitOptions...)
+	r.Plan, err = r.runPlan(tf, planPath)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Unable to initialize Terraform Plan: %s", err))
+		return err
+	}
+
+	if r.ApplyRun {
+		r.ApplyPlanPath = planPath
+	}
+
+	return nil
+}
+
+// runPlan initializes the configuration in tf's working directory and plans it to planPath
+func (r *rover) runPlan(tf *tfexec.Terraform, planPath string) (*tfjson.Plan, error) {
+	if err := r.initTerraform(tf); err != nil {
+		return nil, err
 	}
 
 	if r.WorkspaceName != "" {
 		log.Printf("Running in %s workspace...", r.WorkspaceName)
-		err = tf.WorkspaceSelect(context.Background(), r.WorkspaceName)
+		err := tf.WorkspaceSelect(context.Background(), r.WorkspaceName)
 		if err != nil {
-			return errors.New(fmt.Sprintf("Unable to select workspace (%s): %s", r.WorkspaceName, err))
+			return nil, errors.New(fmt.Sprintf("Unable to select workspace (%s): %s", r.WorkspaceName, err))
 		}
 	}
 
-	log.Println("Generating plan...")
-	planPath := fmt.Sprintf("%s/%s-%v", tmpDir, "roverplan", time.Now().Unix())
+	return r.planTerraform(tf, planPath)
+}
 
-	// Create TF Plan options
-	var tfPlanOptions []tfexec.PlanOption
-	tfPlanOptions = append(tfPlanOptions, tfexec.Out(planPath))
+// initTerraform checks the required version of the configuration in tf's working directory
+// and initializes it, unless -skipInit is set
+func (r *rover) initTerraform(tf *tfexec.Terraform) error {
+	if err := r.checkRequiredVersion(tf.WorkingDir()); err != nil {
+		return err
+	}
+
+	// Share downloaded providers between runs
+	if r.PluginCacheDir != "" {
+		os.Setenv("TF_PLUGIN_CACHE_DIR", r.PluginCacheDir)
+	}
+
+	if r.SkipInit {
+		log.Printf("Skipping %s initialization...", toolName(r.Tool))
+		return nil
+	}
+
+	log.Printf("Initializing %s...", toolName(r.Tool))
+
+	err := tf.Init(context.Background(), r.initOptions()...)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to initialize Terraform Plan: %s", err))
+	}
+
+	return nil
+}
+
+// planTerraform plans the initialized configuration in tf's working directory to planPath
+func (r *rover) planTerraform(tf *tfexec.Terraform, planPath string) (*tfjson.Plan, error) {
+	switch r.PlanMode {
+	case PlanModeDestroy:
+		log.Println("Generating destroy plan...")
+	case PlanModeRefreshOnly:
+		log.Println("Generating refresh-only plan...")
+	default:
+		log.Println("Generating plan...")
+		r.PlanMode = PlanModeNormal
+	}
+
+	var err error
+	if r.PlanMode == PlanModeRefreshOnly {
+		// tfexec doesn't support -refresh-only
+		err = r.runRefreshOnlyPlan(tf, planPath)
+	} else {
+		_, err = tf.Plan(context.Background(), r.planOptions(planPath)...)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to run Plan: %s", err))
+	}
+
+	plan, err := r.showPlanFile(tf, planPath)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read Plan: %s", err))
+	}
+
+	return plan, nil
+}
+
+// initOptions returns the TF Init options for the init flags
+func (r *rover) initOptions() []tfexec.InitOption {
+	var tfInitOptions []tfexec.InitOption
+	tfInitOptions = append(tfInitOptions, tfexec.Upgrade(!r.NoUpgrade))
+
+	// Add *.tfbackend files
+	for _, tfBackendConfig := range r.TfBackendConfigs {
+		if tfBackendConfig != "" {
+			tfInitOptions = append(tfInitOptions, tfexec.BackendConfig(tfBackendConfig))
+		}
+	}
+
+	return tfInitOptions
+}
+
+// planFlag is a flag of terraform plan, -name=value, or -name if it has no value
+type planFlag struct {
+	Name  string
+	Value string
+}
+
+// planFlags returns the terraform plan flags for the plan flags of rover. Both planOptions
+// and planArgs are generated from them, so tfexec and the command line get the same plan.
+func (r *rover) planFlags(planPath string) []planFlag {
+	flags := []planFlag{{"out", planPath}}
 
 	// Add *.tfvars files
 	for _, tfVarsFile := range r.TfVarsFiles {
 		if tfVarsFile != "" {
-			tfPlanOptions = append(tfPlanOptions, tfexec.VarFile(tfVarsFile))
+			flags = append(flags, planFlag{"var-file", tfVarsFile})
 		}
 	}
 
 	// Add Terraform variables
 	for _, tfVar := range r.TfVars {
 		if tfVar != "" {
-			tfPlanOptions = append(tfPlanOptions, tfexec.Var(tfVar))
+			flags = append(flags, planFlag{"var", tfVar})
 		}
 	}
 
-	_, err = tf.Plan(context.Background(), tfPlanOptions...)
-	if err != nil {
-		return errors.New(fmt.Sprintf("Unable to run Plan: %s", err))
+	for _, target := range r.PlanTargets {
+		if target != "" {
+			flags = append(flags, planFlag{"target", target})
+		}
 	}
 
-	r.Plan, err = tf.ShowPlanFile(context.Background(), planPath)
-	if err != nil {
-		return errors.New(fmt.Sprintf("Unable to read Plan: %s", err))
+	for _, replace := range r.PlanReplaces {
+		if replace != "" {
+			flags = append(flags, planFlag{"replace", replace})
+		}
 	}
 
-	return nil
+	switch r.PlanMode {
+	case PlanModeDestroy:
+		flags = append(flags, planFlag{"destroy", ""})
+	case PlanModeRefreshOnly:
+		flags = append(flags, planFlag{"refresh-only", ""})
+	}
+	if r.PlanNoRefresh {
+		flags = append(flags, planFlag{"refresh", "false"})
+	}
+	if r.Parallelism > 0 {
+		flags = append(flags, planFlag{"parallelism", strconv.Itoa(r.Parallelism)})
+	}
+	if r.LockTimeout != "" {
+		flags = append(flags, planFlag{"lock-timeout", r.LockTimeout})
+	}
+
+	return flags
+}
+
+// planOptions returns the TF Plan options for the plan flags. tfexec has no option for
+// -refresh-only, see runRefreshOnlyPlan.
+func (r *rover) planOptions(planPath string) []tfexec.PlanOption {
+	var tfPlanOptions []tfexec.PlanOption
+
+	for _, f := range r.planFlags(planPath) {
+		switch f.Name {
+		case "out":
+			tfPlanOptions = append(tfPlanOptions, tfexec.Out(f.Value))
+		case "var-file":
+			tfPlanOptions = append(tfPlanOptions, tfexec.VarFile(f.Value))
+		case "var":
+			tfPlanOptions = append(tfPlanOptions, tfexec.Var(f.Value))
+		case "target":
+			tfPlanOptions = append(tfPlanOptions, tfexec.Target(f.Value))
+		case "replace":
+			tfPlanOptions = append(tfPlanOptions, tfexec.Replace(f.Value))
+		case "destroy":
+			tfPlanOptions = append(tfPlanOptions, tfexec.Destroy(true))
+		case "refresh":
+			tfPlanOptions = append(tfPlanOptions, tfexec.Refresh(f.Value != "false"))
+		case "parallelism":
+			n, _ := strconv.Atoi(f.Value)
+			tfPlanOptions = append(tfPlanOptions, tfexec.Parallelism(n))
+		case "lock-timeout":
+			tfPlanOptions = append(tfPlanOptions, tfexec.LockTimeout(f.Value))
+		}
+	}
+
+	return tfPlanOptions
+}
+
+// planArgs returns the command line arguments of terraform plan for the plan flags
+func (r *rover) planArgs(planPath string) []string {
+	args := []string{"plan", "-input=false"}
+	for _, f := range r.planFlags(planPath) {
+		if f.Value == "" {
+			args = append(args, fmt.Sprintf("-%s", f.Name))
+		} else {
+			args = append(args, fmt.Sprintf("-%s=%s", f.Name, f.Value))
+		}
+	}
+
+	return args
+}
+
+// runRefreshOnlyPlan runs terraform plan -refresh-only in tf's working directory. tfexec
+// (v0.15) has no -refresh-only option and no way to pass extra arguments, so the plan runs
+// on the command line with the same flags as planOptions.
+func (r *rover) runRefreshOnlyPlan(tf *tfexec.Terraform, planPath string) error {
+	cmd := exec.Command(tf.ExecPath(), r.planArgs(planPath)...)
+	cmd.Dir = tf.WorkingDir()
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
 }
 
 func showJSON(g interface{}) {
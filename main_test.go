@@ -0,0 +1,143 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestPlanArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		r    rover
+		want []string
+	}{
+		{
+			name: "defaults",
+			r:    rover{TfVarsFiles: []string{""}, TfVars: []string{""}, PlanTargets: []string{""}, PlanReplaces: []string{""}},
+			want: []string{"plan", "-input=false", "-out=plan.out"},
+		},
+		{
+			name: "all flags",
+			r: rover{
+				TfVarsFiles:   []string{"prod.tfvars"},
+				TfVars:        []string{"region=eu-west-1"},
+				PlanTargets:   []string{"aws_instance.web"},
+				PlanReplaces:  []string{"aws_instance.db"},
+				PlanMode:      PlanModeDestroy,
+				PlanNoRefresh: true,
+				Parallelism:   4,
+				LockTimeout:   "60s",
+			},
+			want: []string{"plan", "-input=false", "-out=plan.out", "-var-file=prod.tfvars", "-var=region=eu-west-1",
+				"-target=aws_instance.web", "-replace=aws_instance.db", "-destroy", "-refresh=false", "-parallelism=4", "-lock-timeout=60s"},
+		},
+		{
+			name: "refresh-only",
+			r:    rover{PlanMode: PlanModeRefreshOnly},
+			want: []string{"plan", "-input=false", "-out=plan.out", "-refresh-only"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.planArgs("plan.out"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planArgs() = %q, want %q", got, tt.want)
+			}
+
+			// Every flag but -refresh-only has a tfexec option
+			flags := tt.r.planFlags("plan.out")
+			options := tt.r.planOptions("plan.out")
+			want := len(flags)
+			if tt.r.PlanMode == PlanModeRefreshOnly {
+				want--
+			}
+			if len(options) != want {
+				t.Errorf("planOptions() = %d options, want %d", len(options), want)
+			}
+		})
+	}
+}
+
+func TestInferPlanMode(t *testing.T) {
+	change := func(address string, actions ...tfjson.Action) *tfjson.ResourceChange {
+		return &tfjson.ResourceChange{Address: address, Change: &tfjson.Change{Actions: actions}}
+	}
+
+	refreshOnlyJSON := `{
+  "format_version": "1.2",
+  "terraform_version": "1.5.5",
+  "resource_drift": [
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+     "change": {"actions": ["update"], "before": {"tags": {}}, "after": {"tags": {"owner": "ops"}}}}
+  ],
+  "resource_changes": [
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+     "change": {"actions": ["no-op"], "before": {}, "after": {}}}
+  ]
+}`
+	r := &rover{}
+	refreshOnly, err := r.readPlanJSON([]byte(refreshOnlyJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.PlanHasDrift {
+		t.Error("expected PlanHasDrift to be set from resource_drift")
+	}
+
+	tests := []struct {
+		name    string
+		plan    *tfjson.Plan
+		summary *ChangeSummary
+		drifted bool
+		want    string
+	}{
+		{
+			name: "destroy",
+			plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_vpc.main", tfjson.ActionDelete),
+				change("data.aws_ami.ubuntu", tfjson.ActionRead),
+			}},
+			want: PlanModeDestroy,
+		},
+		{
+			name: "replace is not destroy",
+			plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+				change("aws_vpc.main", tfjson.ActionDelete),
+				change("aws_instance.web", tfjson.ActionDelete, tfjson.ActionCreate),
+			}},
+			want: "",
+		},
+		{
+			name:    "refresh-only",
+			plan:    refreshOnly,
+			drifted: r.PlanHasDrift,
+			want:    PlanModeRefreshOnly,
+		},
+		{
+			name: "no changes without drift",
+			plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{change("aws_vpc.main", tfjson.ActionNoop)}},
+			want: "",
+		},
+		{
+			name:    "destroy plan log",
+			plan:    &tfjson.Plan{},
+			summary: &ChangeSummary{Remove: 2, Operation: "destroy"},
+			want:    PlanModeDestroy,
+		},
+		{
+			name: "normal",
+			plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{change("aws_vpc.main", tfjson.ActionCreate)}},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferPlanMode(tt.plan, tt.summary, tt.drifted); got != tt.want {
+				t.Errorf("inferPlanMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ const (
 	ResourceTypeResource ResourceType = "resource"
 	ResourceTypeData     ResourceType = "data"
 	ResourceTypeModule   ResourceType = "module"
+	ResourceTypeCheck    ResourceType = "check"
+	ResourceTypeProvider ResourceType = "provider"
 	DefaultFileName      string       = "unknown file"
 )
 
@@ -43,6 +45,15 @@ const (
 
 	// ActionReplace denotes a replace operation.
 	ActionReplace Action = "replace"
+
+	// ActionImport denotes a resource imported without other changes.
+	ActionImport Action = "import"
+
+	// ActionMove denotes a resource moved to a new address without other changes.
+	ActionMove Action = "move"
+
+	// ActionForget denotes a resource removed from state without being destroyed.
+	ActionForget Action = "forget"
 )
 
 // Map represents the root module
@@ -52,6 +63,8 @@ type Map struct {
 	RequiredProviders map[string]*tfconfig.ProviderRequirement `json:"required_providers,omitempty"`
 	// ProviderConfigs   map[string]*tfconfig.ProviderConfig      `json:"provider_configs,omitempty"`
 	Root map[string]*Resource `json:"root,omitempty"`
+	// Monthly cost of the configuration
+	Cost *Cost `json:"cost,omitempty"`
 }
 
 // Resource is a modified tfconfig.Resource
@@ -65,14 +78,49 @@ type Resource struct {
 	// Resource
 	ChangeAction Action `json:"change_action,omitempty"`
 	// Variable and Output
-	Required  *bool `json:"required,omitempty"`
-	Sensitive bool  `json:"sensitive,omitempty"`
+	Required    *bool  `json:"required,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Variable
+	Value        interface{} `json:"value,omitempty"`
+	VariableType string      `json:"variable_type,omitempty"`
+	Default      interface{} `json:"default,omitempty"`
+	Validations  []Condition `json:"validations,omitempty"`
+	// Output
+	Before       interface{} `json:"before,omitempty"`
+	After        interface{} `json:"after,omitempty"`
+	AfterUnknown bool        `json:"after_unknown,omitempty"`
+	// Resource
+	Lifecycle       *Lifecycle `json:"lifecycle,omitempty"`
+	PreviousAddress string     `json:"previous_address,omitempty"`
+	Importing       bool       `json:"importing,omitempty"`
 	// Provider and Data
 	Provider     string `json:"provider,omitempty"`
 	ResourceType string `json:"resource_type,omitempty"`
+	DocsURL      string `json:"docs_url,omitempty"`
+	// Provider
+	Conflict bool `json:"conflict,omitempty"`
 	// ModuleCall
 	Source  string `json:"source,omitempty"`
 	Version string `json:"version,omitempty"`
+	// CDKTF
+	ConstructPath string `json:"construct_path,omitempty"`
+	// Check block
+	Assertions []Condition `json:"assertions,omitempty"`
+	// Check status of checks, resources, outputs and variables
+	Check *CheckState `json:"check,omitempty"`
+	// Reason the change of a resource was deferred
+	Deferred string `json:"deferred,omitempty"`
+	// Why a resource is replaced
+	Replacement *Replacement `json:"replacement,omitempty"`
+	// Configuration and validate diagnostics
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// Static analyzer findings
+	Findings []*Finding `json:"findings,omitempty"`
+	// Monthly cost of resources, and the total of modules and files
+	Cost *Cost `json:"cost,omitempty"`
+	// Tagging and naming conventions the resource doesn't follow
+	Violations []*Violation `json:"violations,omitempty"`
 }
 
 // ModuleCall is a modified tfconfig.ModuleCall
@@ -86,7 +134,6 @@ type ModuleCall struct {
 func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 
 	childIndex := regexp.MustCompile(`\[[^[\]]*\]$`)
-	matchBrackets := regexp.MustCompile(`\[[^\[\]]*\]`)
 
 	states := r.RSO.States
 	configs := r.RSO.Configs
@@ -97,6 +144,10 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 	}
 
 	parentConfig := matchBrackets.ReplaceAllString(parentModule, "")
+	configPrefix := parentConfig
+	if parentConfig != "" {
+		configPrefix = fmt.Sprintf("%s.", configPrefix)
+	}
 	parentConfigured := configs[parentConfig] != nil && configs[parentConfig].Module != nil
 
 	// Add variables and outputs with line numbers and file names if configured
@@ -105,24 +156,44 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 			fname := filepath.Base(o.Pos.Filename)
 			oid := fmt.Sprintf("%soutput.%s", prefix, oName)
 			out := &Resource{
-				Type:      ResourceTypeOutput,
-				Name:      oName,
-				Sensitive: o.Sensitive,
-				Line:      &o.Pos.Line,
+				Type:        ResourceTypeOutput,
+				Name:        oName,
+				Sensitive:   o.Sensitive,
+				Line:        &o.Pos.Line,
+				Description: o.Description,
 			}
+			r.addOutputValues(out, prefix, configPrefix, oName)
+			out.Check = r.RSO.Checks[oid]
+			out.Diagnostics = r.nodeDiagnostics(parentConfig, fmt.Sprintf("%soutput.%s", configPrefix, oName), "")
+			out.Findings = r.nodeFindings(fmt.Sprintf("%soutput.%s", configPrefix, oName))
 			r.AddFileIfNotExists(parent, parentModule, fname)
 
 			parent.Children[fname].Children[oid] = out
 		}
 
+		validations := VariableValidations(configs[parentConfig].Module.Path)
+
 		for vName, v := range configs[parentConfig].Module.Variables {
 			fname := filepath.Base(v.Pos.Filename)
 			vid := fmt.Sprintf("%svar.%s", prefix, vName)
+			sensitive := r.variableConfigSensitive(fmt.Sprintf("%svar.%s", configPrefix, vName))
 			va := &Resource{
-				Type:     ResourceTypeVariable,
-				Name:     vName,
-				Required: &v.Required,
-				Line:     &v.Pos.Line,
+				Type:         ResourceTypeVariable,
+				Name:         vName,
+				Required:     &v.Required,
+				Line:         &v.Pos.Line,
+				Sensitive:    sensitive,
+				Value:        r.variableValue(parentConfig, vName, sensitive),
+				VariableType: v.Type,
+				Default:      v.Default,
+				Description:  v.Description,
+				Validations:  validations[vName],
+				Check:        r.RSO.Checks[vid],
+				Diagnostics:  r.nodeDiagnostics(parentConfig, fmt.Sprintf("%svar.%s", configPrefix, vName), ""),
+				Findings:     r.nodeFindings(fmt.Sprintf("%svar.%s", configPrefix, vName)),
+			}
+			if sensitive && !r.ShowSensitive && va.Default != nil {
+				va.Default = "Sensitive Value"
 			}
 
 			r.AddFileIfNotExists(parent, parentModule, fname)
@@ -139,15 +210,26 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 				Name:      oName,
 				Sensitive: o.Sensitive,
 			}
+			r.addOutputValues(out, prefix, configPrefix, oName)
+			out.Check = r.RSO.Checks[oid]
 
 			parent.Children[oid] = out
 		}
 
-		for vName := range configs[parentConfig].ModuleConfig.Module.Variables {
+		for vName, v := range configs[parentConfig].ModuleConfig.Module.Variables {
 			vid := fmt.Sprintf("%svar.%s", prefix, vName)
 			va := &Resource{
-				Type: ResourceTypeVariable,
-				Name: vName,
+				Type:         ResourceTypeVariable,
+				Name:         vName,
+				Sensitive:    v.Sensitive,
+				Value:        r.variableValue(parentConfig, vName, v.Sensitive),
+				VariableType: r.variableType(parentConfig, vName, v),
+				Default:      v.Default,
+				Description:  v.Description,
+				Check:        r.RSO.Checks[vid],
+			}
+			if v.Sensitive && !r.ShowSensitive && va.Default != nil {
+				va.Default = "Sensitive Value"
 			}
 
 			parent.Children[vid] = va
@@ -165,17 +247,22 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 			Children: map[string]*Resource{},
 		}
 
-		if states[id].Change.Actions != nil {
-
-			re.ChangeAction = Action(string(states[id].Change.Actions[0]))
-			if len(states[id].Change.Actions) > 1 {
-				re.ChangeAction = ActionReplace
-			}
-		}
+		re.ChangeAction = changeAction(states[id])
+		re.PreviousAddress = states[id].PreviousAddress
+		re.Importing = states[id].Importing != nil
+		re.Check = states[id].Check
+		re.Deferred = states[id].Deferred
+		re.Replacement = states[id].Replacement
+		re.Diagnostics = r.nodeDiagnostics(parentConfig, configId, "")
+		re.Findings = r.nodeFindings(configId)
+		re.Cost = states[id].Cost
+		re.Violations = states[id].Violations
 
 		if rs.Type == ResourceTypeResource || rs.Type == ResourceTypeData {
 			re.ResourceType = configs[configId].ResourceConfig.Type
 			re.Name = configs[configId].ResourceConfig.Name
+			re.Lifecycle = configs[configId].Lifecycle
+			re.DocsURL = configs[configId].DocsURL
 
 			for crName, cr := range states[id].Children {
 
@@ -184,7 +271,8 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 				}
 
 				tcr := &Resource{
-					Type: rs.Type,
+					Type:      rs.Type,
+					Lifecycle: re.Lifecycle,
 				}
 
 				if rs.Type == ResourceTypeData {
@@ -193,13 +281,16 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 					tcr.Name = strings.TrimPrefix(crName, fmt.Sprintf("%s%s.", prefix, re.ResourceType))
 				}
 
-				if cr.Change.Actions != nil {
-					tcr.ChangeAction = Action(string(cr.Change.Actions[0]))
-
-					if len(cr.Change.Actions) > 1 {
-						tcr.ChangeAction = ActionReplace
-					}
-				}
+				tcr.ChangeAction = changeAction(cr)
+				tcr.PreviousAddress = cr.PreviousAddress
+				tcr.Importing = cr.Importing != nil
+				tcr.Check = cr.Check
+				tcr.Deferred = cr.Deferred
+				tcr.Replacement = cr.Replacement
+				tcr.Diagnostics = re.Diagnostics
+				tcr.Findings = re.Findings
+				tcr.Cost = cr.Cost
+				tcr.Violations = cr.Violations
 
 				re.Children[crName] = tcr
 			}
@@ -213,7 +304,17 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 					ind = fmt.Sprintf("data.%s", ind)
 				}
 
-				if rs.Type == ResourceTypeData && configs[parentConfig].Module.DataResources[ind] != nil {
+				if constructPath, ok := r.ConstructPaths[configId]; ok {
+
+					// CDKTF resources are grouped by the construct defining them
+					fname = constructScope(constructPath)
+					re.ConstructPath = constructPath
+
+					r.AddFileIfNotExists(parent, parentModule, fname)
+
+					parent.Children[fname].Children[id] = re
+
+				} else if rs.Type == ResourceTypeData && configs[parentConfig].Module.DataResources[ind] != nil {
 
 					fname = filepath.Base(configs[parentConfig].Module.DataResources[ind].Pos.Filename)
 					re.Line = &configs[parentConfig].Module.DataResources[ind].Pos.Line
@@ -245,6 +346,7 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 
 		} else if rs.Type == ResourceTypeModule {
 			re.Name = strings.Split(id, ".")[len(strings.Split(id, "."))-1]
+			re.Diagnostics = append(re.Diagnostics, r.nodeDiagnostics(configId, "", "")...)
 
 			if configured && !childIndex.MatchString(id) && configs[parentConfig].Module.ModuleCalls[matchBrackets.ReplaceAllString(re.Name, "")] != nil {
 				fname := filepath.Base(configs[parentConfig].Module.ModuleCalls[matchBrackets.ReplaceAllString(re.Name, "")].Pos.Filename)
@@ -260,6 +362,22 @@ func (r *rover) GenerateModuleMap(parent *Resource, parentModule string) {
 
 			r.GenerateModuleMap(re, id)
 
+		} else if rs.Type == ResourceTypeCheck {
+			re.Name = strings.TrimPrefix(id, fmt.Sprintf("%scheck.", prefix))
+
+			if configured && configs[configId].CheckConfig != nil {
+				check := configs[configId].CheckConfig
+				fname := filepath.Base(check.Pos.Filename)
+				re.Line = &check.Pos.Line
+				re.Assertions = check.Assertions
+
+				r.AddFileIfNotExists(parent, parentModule, fname)
+
+				parent.Children[fname].Children[id] = re
+
+			} else {
+				parent.Children[id] = re
+			}
 		}
 
 		// Add locals
@@ -310,6 +428,13 @@ func (r *rover) AddFileIfNotExists(module *Resource, parentModule string, fname
 			Source:   fmt.Sprintf("%s/%s", module.Source, fname),
 			Children: map[string]*Resource{},
 		}
+
+		// Diagnostics of the module explain why its resources are in an unknown file
+		filter := fname
+		if fname == DefaultFileName {
+			filter = ""
+		}
+		module.Children[fname].Diagnostics = r.nodeDiagnostics(matchBrackets.ReplaceAllString(parentModule, ""), "", filter)
 	}
 }
 
@@ -341,11 +466,14 @@ func (r *rover) GenerateMap() error {
 		mapObj.RequiredProviders = rootConfig.RequiredProviders
 		mapObj.RequiredCore = rootConfig.RequiredCore
 		r.GenerateModuleMap(rootModule, "")
+		r.AddProviderNodes(rootModule)
 	} else {
 		r.AddFileIfNotExists(rootModule, "", DefaultFileName)
 		r.GenerateModuleMap(rootModule.Children[DefaultFileName], "")
 	}
 
+	mapObj.Cost = rollupCost(rootModule)
+
 	r.Map = mapObj
 
 	return nil
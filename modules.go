@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Module source types
+const (
+	ModuleSourceLocal     = "local"
+	ModuleSourceRegistry  = "registry"
+	ModuleSourceGit       = "git"
+	ModuleSourceMercurial = "mercurial"
+	ModuleSourceS3        = "s3"
+	ModuleSourceGCS       = "gcs"
+	ModuleSourceHTTP      = "http"
+	ModuleSourceUnknown   = "unknown"
+)
+
+// ModuleTree is a module call with its source, installed version and directory from
+// modules.json, the number of instances and the resources it declares
+type ModuleTree struct {
+	ModuleCall
+	Address         string        `json:"address"`
+	Key             string        `json:"key"`
+	SourceType      string        `json:"source_type,omitempty"`
+	ResolvedVersion string        `json:"resolved_version,omitempty"`
+	Dir             string        `json:"dir,omitempty"`
+	Instances       int           `json:"instances"`
+	Resources       int           `json:"resources"`
+	DataSources     int           `json:"data_sources"`
+	ResourceCount   int           `json:"resource_count"`
+	Children        []*ModuleTree `json:"children,omitempty"`
+}
+
+var registrySource = regexp.MustCompile(`^([0-9A-Za-z.-]+/)?[0-9A-Za-z_-]+/[0-9A-Za-z_-]+/[0-9a-z]+(//.*)?$`)
+
+// moduleSourceType returns the type of a module source, following the source address
+// rules of terraform init
+func moduleSourceType(source string) string {
+	switch {
+	case source == "":
+		return ""
+	case strings.HasPrefix(source, "./"), strings.HasPrefix(source, "../"):
+		return ModuleSourceLocal
+	case strings.HasPrefix(source, "git::"), strings.HasPrefix(source, "github.com/"),
+		strings.HasPrefix(source, "git@"), strings.HasPrefix(source, "bitbucket.org/"):
+		return ModuleSourceGit
+	case strings.HasPrefix(source, "hg::"):
+		return ModuleSourceMercurial
+	case strings.HasPrefix(source, "s3::"), strings.Contains(source, ".s3.amazonaws.com/"),
+		strings.Contains(source, ".s3-") && strings.Contains(source, ".amazonaws.com/"):
+		return ModuleSourceS3
+	case strings.HasPrefix(source, "gcs::"), strings.HasPrefix(source, "www.googleapis.com/storage/"):
+		return ModuleSourceGCS
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return ModuleSourceHTTP
+	case registrySource.MatchString(source):
+		return ModuleSourceRegistry
+	}
+	return ModuleSourceUnknown
+}
+
+// moduleKey returns the modules.json key of a module, e.g. vpc.subnets for
+// module.vpc.module.subnets
+func moduleKey(id string) string {
+	return strings.TrimPrefix(strings.ReplaceAll(id, ".module.", "."), "module.")
+}
+
+// resourceModule returns the module of a resource configuration address, e.g. module.a.module.b
+// for module.a.module.b.aws_instance.web, or an empty string for the root module
+func resourceModule(configId string) string {
+	calls := splitModuleAddress(configId)
+	if len(calls) == 0 {
+		return ""
+	}
+	last := strings.SplitN(calls[len(calls)-1], ".", 3)
+	if last[0] != "module" || len(last) < 3 {
+		return ""
+	}
+	calls[len(calls)-1] = fmt.Sprintf("module.%s", last[1])
+	return strings.Join(calls, ".")
+}
+
+// GenerateModuleTree builds the module hierarchy of the configuration. Instances and
+// resource instances are counted from the plan or state.
+func (r *rover) GenerateModuleTree() error {
+	log.Println("Generating module tree...")
+
+	rc := r.RSO.Configs
+	rs := r.RSO.States
+
+	modules := map[string]*ModuleTree{
+		"": {
+			ModuleCall: ModuleCall{Name: "root"},
+			Dir:        ".",
+			Instances:  1,
+			Children:   []*ModuleTree{},
+		},
+	}
+
+	for id, config := range rc {
+		if id == "" || config.ModuleConfig == nil {
+			continue
+		}
+
+		key := moduleKey(id)
+		name := key[strings.LastIndex(key, ".")+1:]
+
+		mt := &ModuleTree{
+			ModuleCall: ModuleCall{
+				Name:    name,
+				Source:  config.ModuleConfig.Source,
+				Version: config.ModuleConfig.VersionConstraint,
+			},
+			Address:  id,
+			Key:      key,
+			Children: []*ModuleTree{},
+		}
+
+		// The line and version of the call from the parent module's source
+		parent := strings.TrimSuffix(strings.TrimSuffix(id, fmt.Sprintf("module.%s", name)), ".")
+		if p, ok := rc[parent]; ok && p.Module != nil {
+			if call, ok := p.Module.ModuleCalls[name]; ok {
+				mt.Line = call.Pos.Line
+				if mt.Source == "" {
+					mt.Source = call.Source
+				}
+				if mt.Version == "" {
+					mt.Version = call.Version
+				}
+			}
+		}
+
+		if loc, ok := r.ModuleManifest[key]; ok {
+			mt.ResolvedVersion = loc.Version
+			mt.Dir = loc.Dir
+			if mt.Source == "" {
+				mt.Source = loc.Source
+			}
+		}
+		mt.SourceType = moduleSourceType(mt.Source)
+
+		modules[id] = mt
+	}
+
+	// Resources declared in each module
+	for id, config := range rc {
+		resource := config.ResourceConfig
+		if resource == nil {
+			continue
+		}
+		if mt, ok := modules[resourceModule(id)]; ok {
+			if resource.Mode == "data" {
+				mt.DataSources++
+			} else {
+				mt.Resources++
+			}
+		}
+	}
+
+	// Module and resource instances in the plan or state
+	for id, state := range rs {
+		configId := matchBrackets.ReplaceAllString(id, "")
+
+		switch state.Type {
+		case ResourceTypeModule:
+			if mt, ok := modules[configId]; ok && id != "" && !state.IsParent {
+				mt.Instances++
+			}
+		case ResourceTypeResource, ResourceTypeData:
+			// Resources with count or for_each group their instances
+			if len(state.Children) > 0 {
+				continue
+			}
+			if config, ok := rc[configId]; !ok || config.ResourceConfig == nil {
+				continue
+			}
+			if mt, ok := modules[resourceModule(configId)]; ok {
+				mt.ResourceCount++
+			}
+		}
+	}
+
+	ids := []string{}
+	for id := range modules {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		parent := strings.TrimSuffix(strings.TrimSuffix(id, fmt.Sprintf("module.%s", modules[id].Name)), ".")
+		if p, ok := modules[parent]; ok {
+			p.Children = append(p.Children, modules[id])
+		}
+	}
+
+	r.Modules = modules[""]
+
+	return nil
+}
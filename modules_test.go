@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestResourceModule(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"aws_instance.web", ""},
+		{"data.aws_ami.ubuntu", ""},
+		{"module.app.aws_instance.web", "module.app"},
+		{"module.app.data.aws_ami.ubuntu", "module.app"},
+		{"module.app.module.db.aws_db_instance.main", "module.app.module.db"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := resourceModule(tt.id); got != tt.want {
+			t.Errorf("resourceModule(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateModuleTreeCounts(t *testing.T) {
+	// Configurations built from resource changes, e.g. of a plan log, have no address
+	r := &rover{
+		RSO: &ResourcesOverview{
+			Configs: map[string]*ConfigOverview{
+				"":                                {},
+				"aws_vpc.main":                    {ResourceConfig: &tfjson.ConfigResource{}},
+				"module.app":                      {ModuleConfig: &tfjson.ModuleCall{}},
+				"module.app.aws_instance.web":     {ResourceConfig: &tfjson.ConfigResource{}},
+				"module.app.data.aws_ami.ubuntu":  {ResourceConfig: &tfjson.ConfigResource{Mode: tfjson.DataResourceMode}},
+				"module.app.module.db":            {ModuleConfig: &tfjson.ModuleCall{}},
+				"module.app.module.db.aws_db.one": {ResourceConfig: &tfjson.ConfigResource{Address: "aws_db.one"}},
+			},
+			States: map[string]*StateOverview{
+				"module.app":                      {Type: ResourceTypeModule},
+				"module.app.aws_instance.web":     {Type: ResourceTypeResource, Children: map[string]*StateOverview{}},
+				"module.app.aws_instance.web[0]":  {Type: ResourceTypeResource},
+				"module.app.aws_instance.web[1]":  {Type: ResourceTypeResource},
+				"module.app.module.db":            {Type: ResourceTypeModule},
+				"module.app.module.db.aws_db.one": {Type: ResourceTypeResource},
+				"module.app.data.aws_ami.ubuntu":  {Type: ResourceTypeData},
+				"aws_vpc.main":                    {Type: ResourceTypeResource},
+			},
+		},
+	}
+	r.RSO.States["module.app.aws_instance.web"].Children["module.app.aws_instance.web[0]"] = r.RSO.States["module.app.aws_instance.web[0]"]
+
+	if err := r.GenerateModuleTree(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := r.Modules
+	if root.Resources != 1 || root.ResourceCount != 1 || len(root.Children) != 1 {
+		t.Fatalf("root = %d resources, %d instances, %d children, want 1, 1 and 1", root.Resources, root.ResourceCount, len(root.Children))
+	}
+
+	tests := []struct {
+		module        *ModuleTree
+		address       string
+		resources     int
+		dataSources   int
+		resourceCount int
+	}{
+		{root.Children[0], "module.app", 1, 1, 3},
+		{root.Children[0].Children[0], "module.app.module.db", 1, 0, 1},
+	}
+	for _, tt := range tests {
+		mt := tt.module
+		if mt.Address != tt.address || mt.Resources != tt.resources || mt.DataSources != tt.dataSources || mt.ResourceCount != tt.resourceCount {
+			t.Errorf("module %s = %d resources, %d data sources, %d instances, want %s with %d, %d and %d",
+				mt.Address, mt.Resources, mt.DataSources, mt.ResourceCount, tt.address, tt.resources, tt.dataSources, tt.resourceCount)
+		}
+	}
+}
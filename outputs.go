@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+)
+
+// outputStateID returns the ID of an output in rso.States. Root module outputs are keyed
+// by name, as in the plan's output changes.
+func outputStateID(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return fmt.Sprintf("%soutput.%s", prefix, name)
+}
+
+// containsUnknown returns whether an after_unknown structure marks any nested value as unknown
+func containsUnknown(afterUnknown interface{}) bool {
+	switch v := afterUnknown.(type) {
+	case bool:
+		return v
+	case map[string]interface{}:
+		for _, e := range v {
+			if containsUnknown(e) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if containsUnknown(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addOutputValues adds the planned change of an output to out. Values are already masked
+// in rso.States if sensitive. Without a planned change, e.g. for child module outputs,
+// the value is taken from the configuration if it's constant.
+func (r *rover) addOutputValues(out *Resource, prefix string, configPrefix string, name string) {
+	config := r.RSO.Configs[fmt.Sprintf("%soutput.%s", configPrefix, name)]
+	if config != nil && config.OutputConfig != nil && out.Description == "" {
+		out.Description = config.OutputConfig.Description
+	}
+
+	if state, ok := r.RSO.States[outputStateID(prefix, name)]; ok && state.Type == ResourceTypeOutput {
+		change := state.Change
+
+		out.Before = change.Before
+		out.After = change.After
+		if afterUnknown, ok := change.AfterUnknown.(bool); ok && afterUnknown {
+			out.AfterUnknown = true
+			out.After = nil
+		} else if containsUnknown(change.AfterUnknown) {
+			// Partly known, e.g. an object with a computed attribute. After holds the known parts.
+			out.AfterUnknown = true
+		}
+
+		if change.Actions != nil {
+			out.ChangeAction = Action(string(change.Actions[0]))
+			if len(change.Actions) > 1 {
+				out.ChangeAction = ActionReplace
+			}
+		}
+		return
+	}
+
+	if config == nil || config.OutputConfig == nil {
+		return
+	}
+
+	expression := config.OutputConfig.Expression
+	if expression == nil || expression.ExpressionData == nil || expression.ConstantValue == nil {
+		return
+	}
+
+	out.After = expression.ConstantValue
+	if out.Sensitive && !r.ShowSensitive {
+		out.After = "Sensitive Value"
+	}
+}
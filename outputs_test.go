@@ -0,0 +1,117 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestAddOutputValues(t *testing.T) {
+	outputChange := func(actions tfjson.Actions, before, after, afterUnknown interface{}) *StateOverview {
+		return &StateOverview{
+			Type:   ResourceTypeOutput,
+			Change: tfjson.Change{Actions: actions, Before: before, After: after, AfterUnknown: afterUnknown},
+		}
+	}
+	constant := func(value interface{}) *ConfigOverview {
+		return &ConfigOverview{OutputConfig: &tfjson.ConfigOutput{
+			Description: "From configuration",
+			Expression:  &tfjson.Expression{ExpressionData: &tfjson.ExpressionData{ConstantValue: value}},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		output    string
+		sensitive bool
+		states    map[string]*StateOverview
+		configs   map[string]*ConfigOverview
+		want      Resource
+	}{
+		{
+			name:   "known",
+			output: "id",
+			states: map[string]*StateOverview{"id": outputChange(tfjson.Actions{tfjson.ActionUpdate}, "a", "b", false)},
+			want:   Resource{Before: "a", After: "b", ChangeAction: ActionUpdate},
+		},
+		{
+			name:   "unknown",
+			output: "id",
+			states: map[string]*StateOverview{"id": outputChange(tfjson.Actions{tfjson.ActionCreate}, nil, nil, true)},
+			want:   Resource{AfterUnknown: true, ChangeAction: ActionCreate},
+		},
+		{
+			name:   "partly unknown object",
+			output: "endpoint",
+			states: map[string]*StateOverview{"endpoint": outputChange(tfjson.Actions{tfjson.ActionCreate}, nil,
+				map[string]interface{}{"port": float64(443)},
+				map[string]interface{}{"host": true})},
+			want: Resource{After: map[string]interface{}{"port": float64(443)}, AfterUnknown: true, ChangeAction: ActionCreate},
+		},
+		{
+			name:   "partly unknown list",
+			output: "ips",
+			states: map[string]*StateOverview{"ips": outputChange(tfjson.Actions{tfjson.ActionCreate}, nil,
+				[]interface{}{"10.0.0.1", nil},
+				[]interface{}{false, true})},
+			want: Resource{After: []interface{}{"10.0.0.1", nil}, AfterUnknown: true, ChangeAction: ActionCreate},
+		},
+		{
+			name:   "known object",
+			output: "tags",
+			states: map[string]*StateOverview{"tags": outputChange(tfjson.Actions{tfjson.ActionNoop},
+				map[string]interface{}{"env": "prod"},
+				map[string]interface{}{"env": "prod"},
+				map[string]interface{}{})},
+			want: Resource{Before: map[string]interface{}{"env": "prod"}, After: map[string]interface{}{"env": "prod"}, ChangeAction: ActionNoop},
+		},
+		{
+			name:   "replaced",
+			output: "id",
+			states: map[string]*StateOverview{"id": outputChange(tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}, "a", "b", false)},
+			want:   Resource{Before: "a", After: "b", ChangeAction: ActionReplace},
+		},
+		{
+			name:    "child module constant",
+			prefix:  "module.app.",
+			output:  "port",
+			configs: map[string]*ConfigOverview{"module.app.output.port": constant(float64(8080))},
+			want:    Resource{After: float64(8080), Description: "From configuration"},
+		},
+		{
+			name:      "sensitive child module constant",
+			prefix:    "module.app.",
+			output:    "token",
+			sensitive: true,
+			configs:   map[string]*ConfigOverview{"module.app.output.token": constant("secret")},
+			want:      Resource{After: "Sensitive Value", Description: "From configuration", Sensitive: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &rover{RSO: &ResourcesOverview{States: tt.states, Configs: tt.configs}}
+			if r.RSO.Configs == nil {
+				r.RSO.Configs = map[string]*ConfigOverview{}
+			}
+
+			out := &Resource{Sensitive: tt.sensitive}
+			r.addOutputValues(out, tt.prefix, tt.prefix, tt.output)
+
+			if !reflect.DeepEqual(*out, tt.want) {
+				t.Errorf("addOutputValues() = %+v, want %+v", *out, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputStateID(t *testing.T) {
+	if id := outputStateID("", "id"); id != "id" {
+		t.Errorf("outputStateID() = %q, want id", id)
+	}
+	if id := outputStateID("module.app.", "id"); id != "module.app.output.id" {
+		t.Errorf("outputStateID() = %q, want module.app.output.id", id)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// ChangeDetail holds the parts of a resource change that terraform-json doesn't parse
+type ChangeDetail struct {
+	// Address of the resource before it was moved
+	PreviousAddress string `json:"previous_address,omitempty"`
+	// Set if the resource is imported by the plan
+	Importing *Importing `json:"importing,omitempty"`
+	// Why the resource is replaced or deleted, and the attributes forcing replacement
+	ActionReason string          `json:"action_reason,omitempty"`
+	ReplacePaths [][]interface{} `json:"replace_paths,omitempty"`
+}
+
+// Importing describes the import of a resource
+type Importing struct {
+	ID string `json:"id,omitempty"`
+}
+
+// For parsing the fields of resource changes missing from tfjson.ResourceChange
+type rawPlan struct {
+	ResourceChanges []struct {
+		PreviousAddress string `json:"previous_address"`
+		ActionReason    string `json:"action_reason"`
+		Change          struct {
+			Importing    *Importing      `json:"importing"`
+			ReplacePaths [][]interface{} `json:"replace_paths"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+	ResourceDrift   []json.RawMessage `json:"resource_drift"`
+	Checks          []*CheckResult    `json:"checks"`
+	DeferredChanges []*DeferredChange `json:"deferred_changes"`
+}
+
+// readPlanJSON parses the output of terraform show -json, recording the change details
+// of moved and imported resources
+func (r *rover) readPlanJSON(planJSON []byte) (*tfjson.Plan, error) {
+	plan := &tfjson.Plan{}
+	if err := json.Unmarshal(planJSON, plan); err != nil {
+		return nil, err
+	}
+
+	raw := rawPlan{}
+	if err := json.Unmarshal(planJSON, &raw); err != nil {
+		return nil, err
+	}
+
+	// Stacks copy the checks and deferred changes of each unit before reading the next plan
+	r.Checks = raw.Checks
+	r.Deferred = raw.DeferredChanges
+	if len(raw.ResourceDrift) > 0 {
+		r.PlanHasDrift = true
+	}
+
+	// Resource changes are in the same order in both
+	for i, rc := range raw.ResourceChanges {
+		if i >= len(plan.ResourceChanges) {
+			break
+		}
+		if rc.PreviousAddress == "" && rc.Change.Importing == nil && rc.ActionReason == "" && len(rc.Change.ReplacePaths) == 0 {
+			continue
+		}
+		r.addChangeDetail(plan.ResourceChanges[i], &ChangeDetail{
+			PreviousAddress: rc.PreviousAddress,
+			Importing:       rc.Change.Importing,
+			ActionReason:    rc.ActionReason,
+			ReplacePaths:    rc.Change.ReplacePaths,
+		})
+	}
+
+	return plan, nil
+}
+
+func (r *rover) addChangeDetail(rc *tfjson.ResourceChange, detail *ChangeDetail) {
+	if r.ChangeDetails == nil {
+		r.ChangeDetails = map[*tfjson.ResourceChange]*ChangeDetail{}
+	}
+	r.ChangeDetails[rc] = detail
+}
+
+// inferPlanMode returns the mode of a plan rover didn't generate itself: destroy if every
+// change deletes, refresh-only if nothing changes but resources drifted. Returns "" otherwise,
+// since a normal plan can't be told apart from a targeted or refresh-only one.
+func inferPlanMode(plan *tfjson.Plan, summary *ChangeSummary, drifted bool) string {
+	if summary != nil && summary.Operation == PlanModeDestroy {
+		return PlanModeDestroy
+	}
+	if plan == nil {
+		return ""
+	}
+
+	changes, deletes := 0, 0
+	for _, rc := range plan.ResourceChanges {
+		if rc.Change == nil || rc.Change.Actions.NoOp() || rc.Change.Actions.Read() {
+			continue
+		}
+		changes++
+		if rc.Change.Actions.Delete() {
+			deletes++
+		}
+	}
+
+	if changes > 0 && deletes == changes {
+		return PlanModeDestroy
+	}
+	if changes == 0 && drifted {
+		return PlanModeRefreshOnly
+	}
+	return ""
+}
+
+// showPlanFile runs terraform show -json on the plan file at planPath.
+// tf.ShowPlanFile drops the fields terraform-json doesn't know.
+func (r *rover) showPlanFile(tf *tfexec.Terraform, planPath string) (*tfjson.Plan, error) {
+	var stdout bytes.Buffer
+
+	cmd := exec.Command(tf.ExecPath(), "show", "-json", "-no-color", planPath)
+	cmd.Dir = tf.WorkingDir()
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return r.readPlanJSON(stdout.Bytes())
+}
+
+// PlanSummary counts the changes of a plan like terraform's change summary
+func (r *rover) PlanSummary() *ChangeSummary {
+	summary := &ChangeSummary{
+		Operation: "plan",
+	}
+
+	for _, rc := range r.Plan.ResourceChanges {
+		if rc.Change == nil || rc.Mode == tfjson.DataResourceMode {
+			continue
+		}
+
+		actions := rc.Change.Actions
+		switch {
+		case actions.Replace():
+			summary.Add++
+			summary.Remove++
+		case actions.Create():
+			summary.Add++
+		case actions.Update():
+			summary.Change++
+		case actions.Delete():
+			summary.Remove++
+		case len(actions) == 1 && actions[0] == tfjson.Action(ActionForget):
+			summary.Forget++
+		}
+
+		if detail, ok := r.ChangeDetails[rc]; ok {
+			if detail.Importing != nil {
+				summary.Import++
+			}
+			if detail.PreviousAddress != "" && detail.PreviousAddress != rc.Address {
+				summary.Move++
+			}
+		}
+	}
+
+	summary.Deferred = len(r.Deferred)
+
+	return summary
+}
+
+// changeAction returns the change action shown for a resource. Replacements have two
+// actions, and moves and imports without other changes are no-ops in the plan.
+func changeAction(state *StateOverview) Action {
+	actions := state.Change.Actions
+	if actions == nil {
+		return ""
+	}
+
+	if len(actions) > 1 {
+		return ActionReplace
+	}
+
+	action := Action(string(actions[0]))
+	if action == ActionNoop {
+		if state.Importing != nil {
+			return ActionImport
+		}
+		if state.PreviousAddress != "" {
+			return ActionMove
+		}
+	}
+	return action
+}
+
+// changeClasses returns graph node classes for resources that are moved or imported
+// along with another change, e.g. an update
+func changeClasses(re *Resource) []string {
+	classes := []string{}
+	if re.PreviousAddress != "" && re.ChangeAction != ActionMove {
+		classes = append(classes, "moved")
+	}
+	if re.Importing && re.ChangeAction != ActionImport {
+		classes = append(classes, "importing")
+	}
+	return classes
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Diagnostic is a tfjson.Diagnostic with the resource address and module it refers to, if any
+type Diagnostic struct {
+	tfjson.Diagnostic
+	Address string `json:"address,omitempty"`
+	Module  string `json:"module,omitempty"`
+}
+
+// ChangeSummary is the change_summary message emitted at the end of terraform plan -json
+type ChangeSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Remove    int    `json:"remove"`
+	Import    int    `json:"import,omitempty"`
+	Move      int    `json:"move,omitempty"`
+	Forget    int    `json:"forget,omitempty"`
+	Failed    int    `json:"failed_checks,omitempty"`
+	Deferred  int    `json:"deferred,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	// Total change in monthly cost, if any resource is priced
+	CostDelta *float64 `json:"cost_delta,omitempty"`
+}
+
+// PlanLog is the result of parsing a terraform plan -json event stream
+type PlanLog struct {
+	Plan          *tfjson.Plan
+	ResourceDrift []*tfjson.ResourceChange
+	Diagnostics   []Diagnostic
+	Summary       *ChangeSummary
+	ChangeDetails map[*tfjson.ResourceChange]*ChangeDetail
+}
+
+// For parsing terraform plan -json messages
+type planLogMessage struct {
+	Type       string                          `json:"type"`
+	Message    string                          `json:"@message,omitempty"`
+	Terraform  string                          `json:"terraform,omitempty"`
+	Change     *planLogResourceChange          `json:"change,omitempty"`
+	Changes    *ChangeSummary                  `json:"changes,omitempty"`
+	Diagnostic *Diagnostic                     `json:"diagnostic,omitempty"`
+	Outputs    map[string]*planLogOutputChange `json:"outputs,omitempty"`
+	Hook       *planLogHook                    `json:"hook,omitempty"`
+}
+
+type planLogResourceChange struct {
+	Resource         planLogResource  `json:"resource"`
+	PreviousResource *planLogResource `json:"previous_resource,omitempty"`
+	Action           string           `json:"action"`
+	Reason           string           `json:"reason,omitempty"`
+	Importing        *Importing       `json:"importing,omitempty"`
+}
+
+type planLogResource struct {
+	Addr            string      `json:"addr"`
+	Module          string      `json:"module"`
+	Resource        string      `json:"resource"`
+	ImpliedProvider string      `json:"implied_provider"`
+	ResourceType    string      `json:"resource_type"`
+	ResourceName    string      `json:"resource_name"`
+	ResourceKey     interface{} `json:"resource_key"`
+}
+
+type planLogOutputChange struct {
+	Sensitive bool   `json:"sensitive"`
+	Action    string `json:"action"`
+}
+
+// Hooks are emitted by apply (apply_start, apply_progress, apply_complete, apply_errored)
+type planLogHook struct {
+	Resource       planLogResource `json:"resource"`
+	Action         string          `json:"action,omitempty"`
+	IDKey          string          `json:"id_key,omitempty"`
+	IDValue        string          `json:"id_value,omitempty"`
+	ElapsedSeconds float64         `json:"elapsed_seconds,omitempty"`
+}
+
+// parsePlanLogMessage parses a single line of a terraform -json event stream
+// Returns nil if the line is not a JSON message (e.g. CI log noise)
+func parsePlanLogMessage(line string) (*planLogMessage, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, nil
+	}
+
+	msg := &planLogMessage{}
+	if err := json.Unmarshal([]byte(line), msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// planLogActions converts a machine-readable UI change action to plan JSON actions
+func planLogActions(action string) tfjson.Actions {
+	switch action {
+	case "create":
+		return tfjson.Actions{tfjson.ActionCreate}
+	case "read":
+		return tfjson.Actions{tfjson.ActionRead}
+	case "update":
+		return tfjson.Actions{tfjson.ActionUpdate}
+	case "delete":
+		return tfjson.Actions{tfjson.ActionDelete}
+	case "replace":
+		return tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}
+	case "remove":
+		return tfjson.Actions{tfjson.Action(ActionForget)}
+	}
+	// noop, move, import
+	return tfjson.Actions{tfjson.ActionNoop}
+}
+
+func (res planLogResource) resourceChange(action string) *tfjson.ResourceChange {
+	mode := tfjson.ManagedResourceMode
+	if strings.HasPrefix(res.Resource, "data.") {
+		mode = tfjson.DataResourceMode
+	}
+
+	return &tfjson.ResourceChange{
+		Address:       res.Addr,
+		ModuleAddress: res.Module,
+		Mode:          mode,
+		Type:          res.ResourceType,
+		Name:          res.ResourceName,
+		Index:         res.ResourceKey,
+		ProviderName:  res.ImpliedProvider,
+		Change: &tfjson.Change{
+			Actions: planLogActions(action),
+		},
+	}
+}
+
+// Replace reasons of the machine-readable UI that differ from the plan's action_reason
+var planLogReasons = map[string]string{
+	"tainted":              ReplaceBecauseTainted,
+	"requested":            ReplaceByRequest,
+	"cannot_update":        ReplaceBecauseCannotUpdate,
+	"replace_triggered_by": ReplaceByTriggers,
+}
+
+// changeDetail returns the previous address, import and reason of a planned change, if any
+func (c *planLogResourceChange) changeDetail() *ChangeDetail {
+	if c.PreviousResource == nil && c.Importing == nil && c.Reason == "" {
+		return nil
+	}
+
+	detail := &ChangeDetail{
+		Importing:    c.Importing,
+		ActionReason: c.Reason,
+	}
+	if reason, ok := planLogReasons[c.Reason]; ok {
+		detail.ActionReason = reason
+	}
+	if c.PreviousResource != nil {
+		detail.PreviousAddress = c.PreviousResource.Addr
+	}
+	return detail
+}
+
+// ReadPlanLog parses the JSON lines written by terraform plan -json
+func ReadPlanLog(reader io.Reader) (*PlanLog, error) {
+	pl := &PlanLog{
+		Plan: &tfjson.Plan{
+			OutputChanges: map[string]*tfjson.Change{},
+		},
+		ChangeDetails: map[*tfjson.ResourceChange]*ChangeDetail{},
+	}
+
+	changes := map[string]*tfjson.ResourceChange{}
+	order := []string{}
+
+	scanner := bufio.NewScanner(reader)
+	// Messages containing large diagnostics can exceed the default token size
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+
+		msg, err := parsePlanLogMessage(scanner.Text())
+		if err != nil {
+			log.Printf("Skipping malformed plan log line %d: %s\n", lineNum, err)
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "version":
+			pl.Plan.TerraformVersion = msg.Terraform
+		case "planned_change":
+			if msg.Change == nil {
+				continue
+			}
+			addr := msg.Change.Resource.Addr
+			if _, ok := changes[addr]; !ok {
+				order = append(order, addr)
+			}
+			changes[addr] = msg.Change.Resource.resourceChange(msg.Change.Action)
+			if detail := msg.Change.changeDetail(); detail != nil {
+				pl.ChangeDetails[changes[addr]] = detail
+			}
+		case "resource_drift":
+			if msg.Change == nil {
+				continue
+			}
+			pl.ResourceDrift = append(pl.ResourceDrift, msg.Change.Resource.resourceChange(msg.Change.Action))
+		case "change_summary":
+			pl.Summary = msg.Changes
+		case "outputs":
+			for name, o := range msg.Outputs {
+				pl.Plan.OutputChanges[name] = &tfjson.Change{
+					Actions:         planLogActions(o.Action),
+					BeforeSensitive: o.Sensitive,
+					AfterSensitive:  o.Sensitive,
+				}
+			}
+		case "diagnostic":
+			if msg.Diagnostic != nil {
+				pl.Diagnostics = append(pl.Diagnostics, *msg.Diagnostic)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 && pl.Summary == nil {
+		for _, d := range pl.Diagnostics {
+			if d.Severity == tfjson.DiagnosticSeverityError {
+				return nil, errors.New(fmt.Sprintf("Plan failed: %s", d.Summary))
+			}
+		}
+		return nil, errors.New("No planned changes or change summary found, is this the output of terraform plan -json?")
+	}
+
+	for _, addr := range order {
+		pl.Plan.ResourceChanges = append(pl.Plan.ResourceChanges, changes[addr])
+	}
+
+	pl.Plan.Config, pl.Plan.PriorState, pl.Plan.PlannedValues = planLogModules(pl.Plan.ResourceChanges)
+
+	return pl, nil
+}
+
+// planLogModules reconstructs the configuration and state module trees from resource addresses,
+// since the event stream contains neither
+func planLogModules(resourceChanges []*tfjson.ResourceChange) (*tfjson.Config, *tfjson.State, *tfjson.StateValues) {
+	config := &tfjson.Config{
+		RootModule: &tfjson.ConfigModule{},
+	}
+	prior := &tfjson.StateValues{
+		RootModule: &tfjson.StateModule{},
+	}
+	planned := &tfjson.StateValues{
+		RootModule: &tfjson.StateModule{},
+	}
+
+	configResources := map[string]bool{}
+
+	for _, rc := range resourceChanges {
+		// Configuration
+		configModule := config.RootModule
+		configPrefix := ""
+		for _, call := range splitModuleAddress(rc.ModuleAddress) {
+			name := matchBrackets.ReplaceAllString(strings.TrimPrefix(call, "module."), "")
+			configPrefix = fmt.Sprintf("%smodule.%s.", configPrefix, name)
+
+			if configModule.ModuleCalls == nil {
+				configModule.ModuleCalls = map[string]*tfjson.ModuleCall{}
+			}
+			if _, ok := configModule.ModuleCalls[name]; !ok {
+				configModule.ModuleCalls[name] = &tfjson.ModuleCall{
+					Module: &tfjson.ConfigModule{},
+				}
+			}
+			configModule = configModule.ModuleCalls[name].Module
+		}
+
+		address := strings.TrimPrefix(matchBrackets.ReplaceAllString(rc.Address, ""), configPrefix)
+		if !configResources[configPrefix+address] {
+			configResources[configPrefix+address] = true
+			configModule.Resources = append(configModule.Resources, &tfjson.ConfigResource{
+				Address:           address,
+				Mode:              rc.Mode,
+				Type:              rc.Type,
+				Name:              rc.Name,
+				ProviderConfigKey: rc.ProviderName,
+			})
+		}
+
+		// State, deleted resources only exist in prior state
+		values := planned
+		if rc.Change.Actions.Delete() {
+			values = prior
+		}
+
+		stateModule := values.RootModule
+		moduleAddress := ""
+		for _, call := range splitModuleAddress(rc.ModuleAddress) {
+			moduleAddress = strings.TrimPrefix(fmt.Sprintf("%s.%s", moduleAddress, call), ".")
+
+			var child *tfjson.StateModule
+			for _, cm := range stateModule.ChildModules {
+				if cm.Address == moduleAddress {
+					child = cm
+					break
+				}
+			}
+			if child == nil {
+				child = &tfjson.StateModule{Address: moduleAddress}
+				stateModule.ChildModules = append(stateModule.ChildModules, child)
+			}
+			stateModule = child
+		}
+
+		stateModule.Resources = append(stateModule.Resources, &tfjson.StateResource{
+			Address:         rc.Address,
+			Mode:            rc.Mode,
+			Type:            rc.Type,
+			Name:            rc.Name,
+			Index:           rc.Index,
+			ProviderName:    rc.ProviderName,
+			AttributeValues: map[string]interface{}{},
+		})
+	}
+
+	return config, &tfjson.State{Values: prior}, planned
+}
+
+// splitModuleAddress splits module.a["x"].module.b into module.a["x"] and module.b
+func splitModuleAddress(address string) []string {
+	calls := []string{}
+	if address == "" {
+		return calls
+	}
+
+	start := 0
+	inBrackets := false
+	inQuotes := false
+	for i := 0; i < len(address); i++ {
+		switch address[i] {
+		case '"':
+			if i == 0 || address[i-1] != '\\' {
+				inQuotes = !inQuotes
+			}
+		case '[':
+			if !inQuotes {
+				inBrackets = true
+			}
+		case ']':
+			if !inQuotes {
+				inBrackets = false
+			}
+		case '.':
+			if !inQuotes && !inBrackets && strings.HasPrefix(address[i+1:], "module.") {
+				calls = append(calls, address[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(calls, address[start:])
+}
+
+// getPlanFromLog reads the terraform plan -json event stream at r.PlanLogPath
+func (r *rover) getPlanFromLog() error {
+	log.Println("Using provided plan log...")
+
+	planLogFile, err := os.Open(r.PlanLogPath)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to read Plan log (%s): %s", r.PlanLogPath, err))
+	}
+	defer planLogFile.Close()
+
+	pl, err := ReadPlanLog(planLogFile)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to read Plan log (%s): %s", r.PlanLogPath, err))
+	}
+
+	r.Plan = pl.Plan
+	r.ResourceDrift = pl.ResourceDrift
+	r.Diagnostics = append(r.Diagnostics, pl.Diagnostics...)
+	r.ChangeSummary = pl.Summary
+	for rc, detail := range pl.ChangeDetails {
+		r.addChangeDetail(rc, detail)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestSplitModuleAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    []string
+	}{
+		{"", []string{}},
+		{"module.vpc", []string{"module.vpc"}},
+		{"module.vpc.module.subnets", []string{"module.vpc", "module.subnets"}},
+		{`module.vpc["a.module.b"].module.subnets[0]`, []string{`module.vpc["a.module.b"]`, "module.subnets[0]"}},
+		{`module.vpc["quote\".module.x"]`, []string{`module.vpc["quote\".module.x"]`}},
+	}
+
+	for _, tt := range tests {
+		if got := splitModuleAddress(tt.address); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitModuleAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestReadPlanLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		log       string
+		wantErr   string
+		addresses []string
+		actions   []tfjson.Actions
+		modules   []string
+		summary   *ChangeSummary
+		drift     int
+		details   int
+	}{
+		{
+			name: "changes",
+			log: `{"type":"version","terraform":"1.5.0"}
+Some CI noise
+{"type":"planned_change","change":{"resource":{"addr":"aws_instance.web","resource":"aws_instance.web","resource_type":"aws_instance","resource_name":"web","implied_provider":"aws"},"action":"create"}}
+{"type":"planned_change","change":{"resource":{"addr":"module.vpc.aws_subnet.a[0]","module":"module.vpc","resource":"aws_subnet.a[0]","resource_type":"aws_subnet","resource_name":"a","resource_key":0,"implied_provider":"aws"},"action":"replace","reason":"tainted"}}
+{"type":"planned_change","change":{"resource":{"addr":"data.aws_ami.ubuntu","resource":"data.aws_ami.ubuntu","resource_type":"aws_ami","resource_name":"ubuntu","implied_provider":"aws"},"action":"read"}}
+{"type":"resource_drift","change":{"resource":{"addr":"aws_s3_bucket.logs","resource":"aws_s3_bucket.logs","resource_type":"aws_s3_bucket","resource_name":"logs"},"action":"update"}}
+{"type":"change_summary","changes":{"add":2,"change":0,"remove":1,"operation":"plan"}}`,
+			addresses: []string{"aws_instance.web", "module.vpc.aws_subnet.a[0]", "data.aws_ami.ubuntu"},
+			actions: []tfjson.Actions{
+				{tfjson.ActionCreate},
+				{tfjson.ActionDelete, tfjson.ActionCreate},
+				{tfjson.ActionRead},
+			},
+			modules: []string{"vpc"},
+			summary: &ChangeSummary{Add: 2, Remove: 1, Operation: "plan"},
+			drift:   1,
+			details: 1,
+		},
+		{
+			name: "later change replaces earlier one",
+			log: `{"type":"planned_change","change":{"resource":{"addr":"aws_instance.web","resource":"aws_instance.web"},"action":"update"}}
+{"type":"planned_change","change":{"resource":{"addr":"aws_instance.web","resource":"aws_instance.web"},"action":"delete"}}`,
+			addresses: []string{"aws_instance.web"},
+			actions:   []tfjson.Actions{{tfjson.ActionDelete}},
+			modules:   []string{},
+		},
+		{
+			name:      "malformed lines are skipped",
+			log:       "{\"type\":\n{\"type\":\"change_summary\",\"changes\":{\"add\":0,\"operation\":\"plan\"}}",
+			addresses: []string{},
+			actions:   []tfjson.Actions{},
+			modules:   []string{},
+			summary:   &ChangeSummary{Operation: "plan"},
+		},
+		{
+			name:    "failed plan",
+			log:     `{"type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid reference"}}`,
+			wantErr: "Plan failed: Invalid reference",
+		},
+		{
+			name:    "not a plan log",
+			log:     `{"format_version":"1.0"}`,
+			wantErr: "No planned changes or change summary found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl, err := ReadPlanLog(strings.NewReader(tt.log))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadPlanLog() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadPlanLog() error = %v", err)
+			}
+
+			addresses := []string{}
+			actions := []tfjson.Actions{}
+			for _, rc := range pl.Plan.ResourceChanges {
+				addresses = append(addresses, rc.Address)
+				actions = append(actions, rc.Change.Actions)
+			}
+			if !reflect.DeepEqual(addresses, tt.addresses) {
+				t.Errorf("addresses = %q, want %q", addresses, tt.addresses)
+			}
+			if !reflect.DeepEqual(actions, tt.actions) {
+				t.Errorf("actions = %v, want %v", actions, tt.actions)
+			}
+
+			modules := []string{}
+			for name := range pl.Plan.Config.RootModule.ModuleCalls {
+				modules = append(modules, name)
+			}
+			if !reflect.DeepEqual(modules, tt.modules) {
+				t.Errorf("module calls = %q, want %q", modules, tt.modules)
+			}
+
+			if !reflect.DeepEqual(pl.Summary, tt.summary) {
+				t.Errorf("summary = %+v, want %+v", pl.Summary, tt.summary)
+			}
+			if len(pl.ResourceDrift) != tt.drift {
+				t.Errorf("drift = %d, want %d", len(pl.ResourceDrift), tt.drift)
+			}
+			if len(pl.ChangeDetails) != tt.details {
+				t.Errorf("change details = %d, want %d", len(pl.ChangeDetails), tt.details)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// PriceTable is a local price catalogue of monthly prices by resource type, for estimating
+// costs without network access
+type PriceTable struct {
+	Currency  string                 `json:"currency"`
+	Resources map[string][]PriceRule `json:"resources"`
+}
+
+// PriceRule prices resources whose attributes match all the values of Match, e.g.
+// {"instance_type": "t3.micro"}. The monthly price is Monthly plus each PerUnit price
+// times the value of its attribute, e.g. {"size": 0.08} per GB of storage. Nested
+// attributes are separated by dots, e.g. root_block_device.0.volume_size.
+type PriceRule struct {
+	Match   map[string]string  `json:"match,omitempty"`
+	Monthly float64            `json:"monthly,omitempty"`
+	PerUnit map[string]float64 `json:"per_unit,omitempty"`
+}
+
+// getPriceTable reads the price catalogue of -priceTable
+func (r *rover) getPriceTable() error {
+	if r.PriceTablePath == "" {
+		return nil
+	}
+
+	log.Println("Using provided price table...")
+
+	priceJSON, err := ioutil.ReadFile(r.PriceTablePath)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to read price table (%s): %s", r.PriceTablePath, err))
+	}
+
+	r.Prices = &PriceTable{}
+	if err := json.Unmarshal(priceJSON, r.Prices); err != nil {
+		return errors.New(fmt.Sprintf("Unable to read price table (%s): %s", r.PriceTablePath, err))
+	}
+
+	return nil
+}
+
+// attributeValue returns the value of an attribute path in planned or prior values
+func attributeValue(values interface{}, path string) (interface{}, bool) {
+	value := values
+	for _, step := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[step]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
+
+// price returns the monthly price of a resource from the first rule of its type matching
+// its values
+func (pt *PriceTable) price(resourceType string, values interface{}) (float64, bool) {
+	if values == nil {
+		return 0, false
+	}
+
+	for _, rule := range pt.Resources[resourceType] {
+		matches := true
+		for path, expected := range rule.Match {
+			value, ok := attributeValue(values, path)
+			if !ok || fmt.Sprintf("%v", value) != expected {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		price := rule.Monthly
+		for path, unitPrice := range rule.PerUnit {
+			value, ok := attributeValue(values, path)
+			if !ok {
+				continue
+			}
+			if units, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64); err == nil {
+				price += units * unitPrice
+			}
+		}
+		return price, true
+	}
+
+	return 0, false
+}
+
+// PopulatePrices estimates the monthly cost of resource instances from the price table,
+// evaluated against their prior and planned values. Resources already priced by Infracost
+// are kept as is.
+func (r *rover) PopulatePrices(rso *ResourcesOverview) {
+	if r.Prices == nil {
+		return
+	}
+
+	rs := rso.States
+	rc := rso.Configs
+
+	if rso.Currency == "" {
+		rso.Currency = r.Prices.Currency
+	}
+
+	for id, state := range rs {
+		if state.Type != ResourceTypeResource || len(state.Children) > 0 || state.Cost != nil {
+			continue
+		}
+
+		config, ok := rc[matchBrackets.ReplaceAllString(id, "")]
+		if !ok || config.ResourceConfig == nil {
+			continue
+		}
+
+		before, pricedBefore := r.Prices.price(config.ResourceConfig.Type, state.Change.Before)
+		after, pricedAfter := r.Prices.price(config.ResourceConfig.Type, state.Change.After)
+		if !pricedBefore && !pricedAfter {
+			continue
+		}
+
+		state.Cost = addCost(nil, &Cost{
+			Before: before,
+			After:  after,
+		})
+	}
+}
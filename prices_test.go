@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+const testPriceTable = `{
+  "currency": "USD",
+  "resources": {
+    "aws_instance": [
+      {"match": {"instance_type": "t3.micro"}, "monthly": 7.59, "per_unit": {"root_block_device.0.volume_size": 0.08}},
+      {"match": {"instance_type": "t3.large", "monitoring": "true"}, "monthly": 62.85},
+      {"monthly": 30}
+    ],
+    "aws_ebs_volume": [
+      {"per_unit": {"size": 0.08, "iops": 0.005}}
+    ]
+  }
+}`
+
+func TestPriceTablePrice(t *testing.T) {
+	pt := &PriceTable{}
+	if err := json.Unmarshal([]byte(testPriceTable), pt); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		resourceType string
+		values       string
+		want         float64
+		wantOk       bool
+	}{
+		{"match", "aws_instance", `{"instance_type": "t3.micro"}`, 7.59, true},
+		{"match with units", "aws_instance", `{"instance_type": "t3.micro", "root_block_device": [{"volume_size": 20}]}`, 9.19, true},
+		{"match on every value", "aws_instance", `{"instance_type": "t3.large", "monitoring": true}`, 62.85, true},
+		{"fallback rule", "aws_instance", `{"instance_type": "t3.large", "monitoring": false}`, 30, true},
+		{"units only", "aws_ebs_volume", `{"size": 100, "iops": 3000}`, 23, true},
+		{"unknown units", "aws_ebs_volume", `{"size": 100, "iops": null}`, 8, true},
+		{"not a number", "aws_ebs_volume", `{"size": "large"}`, 0, true},
+		{"unpriced type", "aws_s3_bucket", `{"bucket": "logs"}`, 0, false},
+		{"no values", "aws_instance", `null`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values interface{}
+			if err := json.Unmarshal([]byte(tt.values), &values); err != nil {
+				t.Fatal(err)
+			}
+
+			got, ok := pt.price(tt.resourceType, values)
+			if ok != tt.wantOk || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("price() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestAttributeValue(t *testing.T) {
+	var values interface{}
+	if err := json.Unmarshal([]byte(`{"tags": {"env": "prod"}, "disks": [{"size": 10}], "empty": null}`), &values); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		want   interface{}
+		wantOk bool
+	}{
+		{"tags.env", "prod", true},
+		{"disks.0.size", float64(10), true},
+		{"disks.1.size", nil, false},
+		{"disks.x", nil, false},
+		{"tags.env.name", nil, false},
+		{"empty", nil, false},
+		{"missing", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := attributeValue(values, tt.path)
+		if ok != tt.wantOk || got != tt.want {
+			t.Errorf("attributeValue(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
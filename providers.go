@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// LockFileName is the dependency lock file written by terraform init
+const LockFileName = ".terraform.lock.hcl"
+
+// DefaultRegistry is the registry of providers without a host in their source
+const DefaultRegistry = "registry.terraform.io"
+
+// LockedProvider is a provider selection in the dependency lock file
+type LockedProvider struct {
+	Version     string   `json:"version"`
+	Constraints string   `json:"constraints,omitempty"`
+	Hashes      []string `json:"hashes,omitempty"`
+}
+
+// Provider is a provider used by the configuration, with the version selected in the lock
+// file and the requirements of each module
+type Provider struct {
+	Source   string                 `json:"source"`
+	Locked   *LockedProvider        `json:"locked,omitempty"`
+	Modules  []*ProviderRequirement `json:"modules"`
+	Conflict bool                   `json:"conflict,omitempty"`
+}
+
+// ProviderRequirement is the requirement of a module on a provider, with the version
+// selected in the lock file of the module's configuration or stack unit
+type ProviderRequirement struct {
+	Module      string          `json:"module"`
+	LocalName   string          `json:"local_name"`
+	Constraints []string        `json:"constraints,omitempty"`
+	Aliases     []string        `json:"aliases,omitempty"`
+	Locked      *LockedProvider `json:"locked,omitempty"`
+	Conflict    bool            `json:"conflict,omitempty"`
+}
+
+var lockFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "provider", LabelNames: []string{"source"}},
+	},
+}
+
+var lockedProviderSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "version"},
+		{Name: "constraints"},
+		{Name: "hashes"},
+	},
+}
+
+// ReadLockFile returns the provider selections of the dependency lock file in dir,
+// keyed by provider source
+func ReadLockFile(dir string) map[string]*LockedProvider {
+	locked := map[string]*LockedProvider{}
+
+	fname := filepath.Join(dir, LockFileName)
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return locked
+	}
+
+	file, diags := hclparse.NewParser().ParseHCL(src, fname)
+	if diags.HasErrors() {
+		log.Printf("Unable to parse %s: %s\n", LockFileName, diags.Error())
+		return locked
+	}
+
+	content, _, _ := file.Body.PartialContent(lockFileSchema)
+	for _, block := range content.Blocks {
+		attrs, _, _ := block.Body.PartialContent(lockedProviderSchema)
+		provider := &LockedProvider{}
+
+		if attr, ok := attrs.Attributes["version"]; ok {
+			provider.Version = stringAttribute(attr)
+		}
+		if attr, ok := attrs.Attributes["constraints"]; ok {
+			provider.Constraints = stringAttribute(attr)
+		}
+		if attr, ok := attrs.Attributes["hashes"]; ok {
+			exprs, _ := hcl.ExprList(attr.Expr)
+			for _, expr := range exprs {
+				if val, diags := expr.Value(nil); !diags.HasErrors() && val.IsKnown() && !val.IsNull() {
+					provider.Hashes = append(provider.Hashes, val.AsString())
+				}
+			}
+		}
+
+		locked[block.Labels[0]] = provider
+	}
+
+	return locked
+}
+
+func stringAttribute(attr *hcl.Attribute) string {
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !val.IsKnown() || val.IsNull() {
+		return ""
+	}
+	return val.AsString()
+}
+
+// providerSource returns the fully qualified source of a provider, e.g.
+// registry.terraform.io/hashicorp/aws for aws or hashicorp/aws
+func providerSource(localName string, source string) string {
+	if source == "" {
+		source = fmt.Sprintf("hashicorp/%s", localName)
+	}
+	if len(strings.Split(source, "/")) == 2 {
+		source = fmt.Sprintf("%s/%s", DefaultRegistry, source)
+	}
+	return strings.ToLower(source)
+}
+
+// lockedProvider finds the lock of a provider
+func lockedProvider(locks map[string]*LockedProvider, source string) *LockedProvider {
+	if locked, ok := locks[source]; ok {
+		return locked
+	}
+
+	for lockSource, locked := range locks {
+		if sameProvider(source, lockSource) {
+			return locked
+		}
+	}
+	return nil
+}
+
+// sameProvider checks if other is the provider with the source. OpenTofu installs
+// providers without a host in their source from its own registry.
+func sameProvider(source string, other string) bool {
+	if source == other {
+		return true
+	}
+
+	parts := strings.Split(source, "/")
+	if len(parts) != 3 || parts[0] != DefaultRegistry {
+		return false
+	}
+	return strings.HasSuffix(other, fmt.Sprintf("/%s/%s", parts[1], parts[2]))
+}
+
+// lockFileDir returns the directory of the lock file that applies to a module: the
+// directory of its stack unit (e.g. a Terragrunt unit), or the working directory
+func (r *rover) lockFileDir(moduleId string) string {
+	for key, dir := range r.Units {
+		prefix := fmt.Sprintf("module.%s", key)
+		if moduleId == prefix || strings.HasPrefix(moduleId, fmt.Sprintf("%s.", prefix)) {
+			return dir
+		}
+	}
+	return r.WorkingDir
+}
+
+// satisfies checks if a provider version meets all of the version constraints
+func satisfies(providerVersion *version.Version, constraints []string) bool {
+	for _, c := range constraints {
+		constraint, err := version.NewConstraint(c)
+		if err != nil {
+			continue
+		}
+		if !constraint.Check(providerVersion) {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateProviderInventory lists the providers required by the configuration and its
+// modules with their locked versions. Modules whose constraints aren't met by the locked
+// version, or by an exact version required by another module, are flagged as conflicting.
+func (r *rover) GenerateProviderInventory() error {
+	log.Println("Generating provider inventory...")
+
+	// Lock files by directory, as each unit of a stack has its own
+	locks := map[string]map[string]*LockedProvider{}
+	providers := map[string]*Provider{}
+
+	moduleIds := []string{}
+	for moduleId := range r.RSO.Configs {
+		moduleIds = append(moduleIds, moduleId)
+	}
+	sort.Strings(moduleIds)
+
+	for _, moduleId := range moduleIds {
+		config := r.RSO.Configs[moduleId]
+		if config.Module == nil || (moduleId != "" && config.ModuleConfig == nil) {
+			continue
+		}
+
+		// Provider configurations in the module, by local name
+		aliases := map[string][]string{}
+		for _, pc := range config.Module.ProviderConfigs {
+			if pc.Alias != "" {
+				aliases[pc.Name] = append(aliases[pc.Name], fmt.Sprintf("%s.%s", pc.Name, pc.Alias))
+			}
+		}
+
+		dir := r.lockFileDir(moduleId)
+		if _, ok := locks[dir]; !ok {
+			locks[dir] = ReadLockFile(dir)
+		}
+
+		for localName, requirement := range config.Module.RequiredProviders {
+			source := providerSource(localName, requirement.Source)
+			if _, ok := providers[source]; !ok {
+				providers[source] = &Provider{
+					Source:  source,
+					Modules: []*ProviderRequirement{},
+				}
+			}
+
+			pr := &ProviderRequirement{
+				Module:      moduleId,
+				LocalName:   localName,
+				Constraints: requirement.VersionConstraints,
+				Aliases:     aliases[localName],
+				Locked:      lockedProvider(locks[dir], source),
+			}
+			if providers[source].Locked == nil {
+				providers[source].Locked = pr.Locked
+			}
+			for _, alias := range requirement.ConfigurationAliases {
+				pr.Aliases = append(pr.Aliases, fmt.Sprintf("%s.%s", alias.Name, alias.Alias))
+			}
+
+			providers[source].Modules = append(providers[source].Modules, pr)
+		}
+	}
+
+	inventory := []*Provider{}
+	for _, provider := range providers {
+		// Versions to check the constraints against without a lock: exact versions
+		// required by modules
+		pinned := []*version.Version{}
+		for _, pr := range provider.Modules {
+			for _, c := range pr.Constraints {
+				if v, err := version.NewVersion(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(c), "="))); err == nil {
+					pinned = append(pinned, v)
+				}
+			}
+		}
+
+		for _, pr := range provider.Modules {
+			versions := pinned
+			if pr.Locked != nil {
+				versions = []*version.Version{}
+				if v, err := version.NewVersion(pr.Locked.Version); err == nil {
+					versions = append(versions, v)
+				}
+			}
+
+			for _, v := range versions {
+				if !satisfies(v, pr.Constraints) {
+					pr.Conflict = true
+					provider.Conflict = true
+				}
+			}
+		}
+
+		inventory = append(inventory, provider)
+	}
+
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].Source < inventory[j].Source
+	})
+
+	r.Providers = inventory
+
+	return nil
+}
+
+// providerID returns the node ID of a provider, e.g. provider.hashicorp.aws
+func providerID(source string) string {
+	source = strings.TrimPrefix(source, fmt.Sprintf("%s/", DefaultRegistry))
+	return fmt.Sprintf("provider.%s", strings.ReplaceAll(source, "/", "."))
+}
+
+// providerNodeID returns the node ID of the provider of the inventory with the source, or
+// an empty string if the configuration doesn't require it
+func (r *rover) providerNodeID(source string) string {
+	if source == "" {
+		return ""
+	}
+	for _, provider := range r.Providers {
+		if sameProvider(provider.Source, source) {
+			return providerID(provider.Source)
+		}
+	}
+	return ""
+}
+
+// resourceProviders returns the provider source of the resources in the plan or state,
+// keyed by configuration address
+func (r *rover) resourceProviders() map[string]string {
+	providers := map[string]string{}
+
+	var walk func(module *tfjson.StateModule)
+	walk = func(module *tfjson.StateModule) {
+		if module == nil {
+			return
+		}
+		for _, rst := range module.Resources {
+			providers[matchBrackets.ReplaceAllString(rst.Address, "")] = rst.ProviderName
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+
+	if r.State != nil && r.State.Values != nil {
+		walk(r.State.Values.RootModule)
+	}
+	if r.Plan != nil {
+		if r.Plan.PriorState != nil && r.Plan.PriorState.Values != nil {
+			walk(r.Plan.PriorState.Values.RootModule)
+		}
+		for _, rc := range r.Plan.ResourceChanges {
+			providers[matchBrackets.ReplaceAllString(rc.Address, "")] = rc.ProviderName
+		}
+	}
+	for _, deferred := range r.Deferred {
+		if rc := deferred.ResourceChange; rc != nil {
+			providers[matchBrackets.ReplaceAllString(rc.Address, "")] = rc.ProviderName
+		}
+	}
+
+	return providers
+}
+
+// addProviderEdges links resources and data sources, but not their instances, to the node
+// of their provider
+func (r *rover) addProviderEdges(edgeMap map[string]Edge, providers map[string]string, resources map[string]*Resource) []string {
+	emo := []string{}
+
+	for id, re := range resources {
+		if re.Type != ResourceTypeResource && re.Type != ResourceTypeData {
+			emo = append(emo, r.addProviderEdges(edgeMap, providers, re.Children)...)
+			continue
+		}
+
+		if targetId := r.providerNodeID(providers[matchBrackets.ReplaceAllString(id, "")]); targetId != "" {
+			edgeId := fmt.Sprintf("%s->%s", id, targetId)
+			emo = append(emo, edgeId)
+			edgeMap[edgeId] = Edge{
+				Data: EdgeData{
+					ID:       edgeId,
+					Source:   id,
+					Target:   targetId,
+					Gradient: fmt.Sprintf("%s %s", getResourceColor(re.Type), getResourceColor(ResourceTypeProvider)),
+				},
+				Classes: "edge provider-edge",
+			}
+		}
+	}
+
+	return emo
+}
+
+// AddProviderNodes adds the providers of the inventory to the root module of the map
+func (r *rover) AddProviderNodes(root *Resource) {
+	for _, provider := range r.Providers {
+		re := &Resource{
+			Type:     ResourceTypeProvider,
+			Name:     provider.Source,
+			Source:   provider.Source,
+			Conflict: provider.Conflict,
+		}
+		if provider.Locked != nil {
+			re.Version = provider.Locked.Version
+		}
+
+		root.Children[providerID(provider.Source)] = re
+	}
+}
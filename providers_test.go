@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func testLockFile(t *testing.T, version string) string {
+	dir := t.TempDir()
+	lock := `provider "registry.terraform.io/hashicorp/aws" {
+  version     = "` + version + `"
+  constraints = "~> 5.0"
+  hashes      = ["h1:abc"]
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, LockFileName), []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func testModuleConfig(constraints ...string) *ConfigOverview {
+	return &ConfigOverview{
+		Module: &tfconfig.Module{
+			RequiredProviders: map[string]*tfconfig.ProviderRequirement{
+				"aws": {Source: "hashicorp/aws", VersionConstraints: constraints},
+			},
+		},
+		ModuleConfig: &tfjson.ModuleCall{},
+	}
+}
+
+func TestGenerateProviderInventoryUnits(t *testing.T) {
+	r := &rover{
+		WorkingDir: t.TempDir(),
+		Units: map[string]string{
+			"app": testLockFile(t, "5.1.0"),
+			"vpc": testLockFile(t, "4.67.0"),
+		},
+		RSO: &ResourcesOverview{
+			Configs: map[string]*ConfigOverview{
+				"":                       {Module: &tfconfig.Module{}},
+				"module.app":             testModuleConfig("~> 5.0"),
+				"module.app.module.tags": testModuleConfig(">= 5.0"),
+				"module.vpc":             testModuleConfig(">= 5.0"),
+			},
+		},
+	}
+
+	if err := r.GenerateProviderInventory(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Providers) != 1 {
+		t.Fatalf("providers = %d, want 1", len(r.Providers))
+	}
+
+	provider := r.Providers[0]
+	if provider.Source != "registry.terraform.io/hashicorp/aws" || !provider.Conflict {
+		t.Errorf("provider = %s, conflict = %v, want a conflicting aws provider", provider.Source, provider.Conflict)
+	}
+
+	tests := []struct {
+		module   string
+		locked   string
+		conflict bool
+	}{
+		{"module.app", "5.1.0", false},
+		{"module.app.module.tags", "5.1.0", false},
+		{"module.vpc", "4.67.0", true},
+	}
+	if len(provider.Modules) != len(tests) {
+		t.Fatalf("modules = %d, want %d", len(provider.Modules), len(tests))
+	}
+	for i, tt := range tests {
+		pr := provider.Modules[i]
+		if pr.Module != tt.module || pr.Locked == nil || pr.Locked.Version != tt.locked || pr.Conflict != tt.conflict {
+			t.Errorf("module %d = %s locked %+v conflict %v, want %s locked %s conflict %v", i, pr.Module, pr.Locked, pr.Conflict, tt.module, tt.locked, tt.conflict)
+		}
+	}
+}
+
+func TestProviderNodeID(t *testing.T) {
+	r := &rover{Providers: []*Provider{
+		{Source: "registry.terraform.io/hashicorp/aws"},
+		{Source: "example.com/acme/widget"},
+	}}
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"registry.terraform.io/hashicorp/aws", "provider.hashicorp.aws"},
+		{"registry.opentofu.org/hashicorp/aws", "provider.hashicorp.aws"},
+		{"example.com/acme/widget", "provider.example.com.acme.widget"},
+		{"other.com/acme/widget", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := r.providerNodeID(tt.source); got != tt.want {
+			t.Errorf("providerNodeID(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
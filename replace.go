@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Reasons for replacing a resource, as in the plan's action_reason
+const (
+	ReplaceBecauseTainted      = "replace_because_tainted"
+	ReplaceBecauseCannotUpdate = "replace_because_cannot_update"
+	ReplaceByRequest           = "replace_by_request"
+	ReplaceByTriggers          = "replace_by_triggers"
+)
+
+// Replacement explains why a resource is replaced
+type Replacement struct {
+	Reason      string                 `json:"reason,omitempty"`
+	Attributes  []ReplacementAttribute `json:"attributes,omitempty"`
+	Explanation string                 `json:"explanation"`
+}
+
+// ReplacementAttribute is an attribute that forces replacement, with the references of the
+// config expression setting it
+type ReplacementAttribute struct {
+	Path       string   `json:"path"`
+	References []string `json:"references,omitempty"`
+	// Attribute of the provider schema at path, e.g. ebs_block_device.volume_size
+	Attribute string `json:"attribute,omitempty"`
+}
+
+// replacePath formats a path of replace_paths like ebs_block_device[0].volume_size
+func replacePath(path []interface{}) string {
+	formatted := ""
+	for _, step := range path {
+		switch s := step.(type) {
+		case string:
+			if formatted != "" {
+				formatted += "."
+			}
+			formatted += s
+		case float64:
+			formatted += fmt.Sprintf("[%d]", int(s))
+		default:
+			formatted += fmt.Sprintf("[%v]", s)
+		}
+	}
+	return formatted
+}
+
+// pathExpression returns the config expression setting the attribute at path, following
+// nested blocks
+func pathExpression(expressions map[string]*tfjson.Expression, path []interface{}) *tfjson.Expression {
+	var expression *tfjson.Expression
+
+	for i := 0; i < len(path); i++ {
+		name, ok := path[i].(string)
+		if !ok || expressions == nil {
+			break
+		}
+
+		expression = expressions[name]
+		if expression == nil || expression.ExpressionData == nil || len(expression.NestedBlocks) == 0 {
+			break
+		}
+
+		// Nested block, e.g. ebs_block_device[0].volume_size
+		block := 0
+		if i+1 < len(path) {
+			if index, ok := path[i+1].(float64); ok {
+				block = int(index)
+				i++
+			}
+		}
+		if block >= len(expression.NestedBlocks) {
+			break
+		}
+		expressions = expression.NestedBlocks[block]
+	}
+
+	return expression
+}
+
+// upstreamReferences drops references that are contained by a more specific one,
+// e.g. aws_vpc.main if aws_vpc.main.id is referenced
+func upstreamReferences(references []string) []string {
+	upstream := []string{}
+	for _, reference := range references {
+		contained := false
+		for _, other := range references {
+			if strings.HasPrefix(other, fmt.Sprintf("%s.", reference)) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			upstream = append(upstream, reference)
+		}
+	}
+	sort.Strings(upstream)
+	return upstream
+}
+
+// explainReplacement explains why a resource is replaced from the action reason and
+// replace paths of its change, resolving the paths to config expressions and references
+func (r *rover) explainReplacement(rso *ResourcesOverview, resource *tfjson.ResourceChange, detail *ChangeDetail) *Replacement {
+	configId := matchBrackets.ReplaceAllString(resource.Address, "")
+
+	replacement := &Replacement{
+		Reason: detail.ActionReason,
+	}
+
+	var expressions map[string]*tfjson.Expression
+	var lifecycle *Lifecycle
+	if config, ok := rso.Configs[configId]; ok {
+		if config.ResourceConfig != nil {
+			expressions = config.ResourceConfig.Expressions
+		}
+		lifecycle = config.Lifecycle
+	}
+
+	for _, path := range detail.ReplacePaths {
+		attribute := ReplacementAttribute{
+			Path: replacePath(path),
+		}
+		if expression := pathExpression(expressions, path); expression != nil && expression.ExpressionData != nil {
+			attribute.References = upstreamReferences(expression.References)
+		}
+		replacement.Attributes = append(replacement.Attributes, attribute)
+	}
+
+	switch detail.ActionReason {
+	case ReplaceBecauseTainted:
+		replacement.Explanation = "Replaced because it is tainted."
+	case ReplaceByRequest:
+		replacement.Explanation = "Replaced as requested with -replace."
+	case ReplaceByTriggers:
+		replacement.Explanation = "Replaced because of replace_triggered_by."
+		if lifecycle != nil && len(lifecycle.ReplaceTriggeredBy) > 0 {
+			replacement.Explanation = fmt.Sprintf("Replaced because of a change to %s (replace_triggered_by).", strings.Join(lifecycle.ReplaceTriggeredBy, ", "))
+		}
+	default:
+		replacement.Explanation = "Replaced because it cannot be updated in place."
+	}
+
+	for _, attribute := range replacement.Attributes {
+		if len(attribute.References) > 0 {
+			replacement.Explanation += fmt.Sprintf(" %s forces replacement and is set from %s.", attribute.Path, strings.Join(attribute.References, ", "))
+		} else {
+			replacement.Explanation += fmt.Sprintf(" %s forces replacement.", attribute.Path)
+		}
+	}
+
+	return replacement
+}
@@ -13,22 +13,71 @@ import (
 	"strings"
 )
 
+// matchBrackets matches the index of a resource or module instance, e.g. [0] or ["a"],
+// to get the configuration address of an instance
+var matchBrackets = regexp.MustCompile(`\[[^\[\]]*\]`)
+
+const (
+	// ModePlan denotes a visualization of a plan
+	ModePlan string = "plan"
+
+	// ModeState denotes a visualization of the current state, without a plan
+	ModeState string = "state"
+
+	// ModeStateDiff denotes a visualization of the difference between two states
+	ModeStateDiff string = "state-diff"
+)
+
+const (
+	// PlanModeNormal denotes a plan to converge infrastructure with configuration
+	PlanModeNormal string = "normal"
+
+	// PlanModeDestroy denotes a plan to destroy all managed infrastructure
+	PlanModeDestroy string = "destroy"
+
+	// PlanModeRefreshOnly denotes a plan that only updates state to match infrastructure
+	PlanModeRefreshOnly string = "refresh-only"
+)
+
 // ResourcesOverview represents the root module
 type ResourcesOverview struct {
-	Locations map[string]string          `json:"locations,omitempty"`
-	States    map[string]*StateOverview  `json:"states,omitempty"`
-	Configs   map[string]*ConfigOverview `json:"configs,omitempty"`
+	Mode        string                     `json:"mode,omitempty"`
+	PlanMode    string                     `json:"plan_mode,omitempty"`
+	Tool        string                     `json:"tool,omitempty"`
+	ToolVersion string                     `json:"tool_version,omitempty"`
+	Locations   map[string]string          `json:"locations,omitempty"`
+	States      map[string]*StateOverview  `json:"states,omitempty"`
+	Configs     map[string]*ConfigOverview `json:"configs,omitempty"`
+	Diagnostics []Diagnostic               `json:"diagnostics,omitempty"`
+	Summary     *ChangeSummary             `json:"summary,omitempty"`
+	Checks      map[string]*CheckState     `json:"checks,omitempty"`
+	Findings    []*Finding                 `json:"findings,omitempty"`
+	Currency    string                     `json:"currency,omitempty"`
 }
 
 // ResourceOverview is a modified tfjson.Plan
 type StateOverview struct {
 	// ChangeAction tfjson.Actions        `json:change_action`
 	Change    tfjson.Change             `json:"change,omitempty"`
+	Drift     *tfjson.Change            `json:"drift,omitempty"`
 	Module    *tfjson.StateModule       `json:"module,omitempty"`
 	DependsOn []string                  `json:"depends_on,omitempty"`
 	Children  map[string]*StateOverview `json:"children,omitempty"`
 	Type      ResourceType              `json:"type,omitempty"`
 	IsParent  bool                      `json:"isparent,omitempty"`
+	// Moved and imported resources
+	PreviousAddress string     `json:"previous_address,omitempty"`
+	Importing       *Importing `json:"importing,omitempty"`
+	// Check status of resources, outputs and check blocks
+	Check *CheckState `json:"check,omitempty"`
+	// Reason the change of a resource was deferred
+	Deferred string `json:"deferred,omitempty"`
+	// Why a resource is replaced
+	Replacement *Replacement `json:"replacement,omitempty"`
+	// Monthly cost from Infracost or the price table
+	Cost *Cost `json:"cost,omitempty"`
+	// Tagging and naming conventions the resource doesn't follow
+	Violations []*Violation `json:"violations,omitempty"`
 }
 
 type ConfigOverview struct {
@@ -37,6 +86,13 @@ type ConfigOverview struct {
 	VariableConfig *tfjson.ConfigVariable `json:"variable_config,omitempty"`
 	OutputConfig   *tfjson.ConfigOutput   `json:"output_config,omitempty"`
 	Module         *tfconfig.Module       `json:"module,omitempty"`
+	Lifecycle      *Lifecycle             `json:"lifecycle,omitempty"`
+	CheckConfig    *CheckConfig           `json:"check_config,omitempty"`
+	// Provider schema of resource attributes
+	Attributes map[string]*Attribute `json:"attributes,omitempty"`
+	DocsURL    string                `json:"docs_url,omitempty"`
+	// Diagnostics of loading the module from the filesystem
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
 }
 
 // For parsing modules.json
@@ -45,9 +101,10 @@ type ModuleLocations struct {
 }
 
 type ModuleLocation struct {
-	Key    string `json:"Key,omitempty""`
-	Source string `json:"Source,omitempty"`
-	Dir    string `json:"Dir,omitempty"`
+	Key     string `json:"Key,omitempty"`
+	Source  string `json:"Source,omitempty"`
+	Version string `json:"Version,omitempty"`
+	Dir     string `json:"Dir,omitempty"`
 }
 
 // PopulateModuleLocations Parses the modules.json file in the .terraform folder, if it exists
@@ -56,24 +113,95 @@ type ModuleLocation struct {
 func (r *rover) PopulateModuleLocations(moduleJSONFile string, locations map[string]string) {
 
 	moduleLocations := ModuleLocations{}
+	r.ModuleManifest = map[string]ModuleLocation{}
 
-	jsonFile, err := os.Open(moduleJSONFile)
+	byteValue, err := ioutil.ReadFile(moduleJSONFile)
 	if err != nil {
-		log.Println("No submodule configurations found...")
+		if os.IsNotExist(err) {
+			log.Println("No submodule configurations found...")
+		} else {
+			log.Printf("Unable to read %s: %s\n", moduleJSONFile, err)
+		}
+		return
 	}
-	defer jsonFile.Close()
-
-	// read our opened jsonFile as a byte array.
-	byteValue, _ := ioutil.ReadAll(jsonFile)
 
-	// we unmarshal our byteArray which contains our
-	// jsonFile's content into 'users' which we defined above
-	json.Unmarshal(byteValue, &moduleLocations)
+	err = json.Unmarshal(byteValue, &moduleLocations)
+	if err != nil {
+		log.Printf("Unable to parse %s, continuing without submodule locations: %s\n", moduleJSONFile, err)
+		return
+	}
 
 	for _, loc := range moduleLocations.Locations {
-		locations[loc.Key] = fmt.Sprintf("%s/%s", r.WorkingDir, loc.Dir)
-		//fmt.Printf("%v\n", loc.Dir)
+		locations[loc.Key] = r.moduleDir(loc.Dir)
+		r.ModuleManifest[loc.Key] = loc
+	}
+}
+
+// moduleDirs returns the directory of the root module and each child module, keyed by module
+// ID. Modules tfconfig couldn't load, or missing from the plan's configuration, are found from
+// their location in modules.json. The directory is "" if the source isn't available locally.
+func moduleDirs(rso *ResourcesOverview) map[string]string {
+	dirs := map[string]string{}
+
+	for moduleId, config := range rso.Configs {
+		if moduleId != "" && config.ModuleConfig == nil {
+			continue
+		}
+
+		if config.Module != nil {
+			dirs[moduleId] = config.Module.Path
+		} else if moduleId != "" {
+			key := strings.ReplaceAll(strings.TrimPrefix(moduleId, "module."), ".module.", ".")
+			dirs[moduleId] = rso.Locations[key]
+		}
+	}
+
+	for key, dir := range rso.Locations {
+		moduleId := fmt.Sprintf("module.%s", strings.ReplaceAll(key, ".", ".module."))
+		if _, ok := dirs[moduleId]; !ok && key != "" {
+			dirs[moduleId] = dir
+		}
 	}
+
+	return dirs
+}
+
+// moduleDir returns the path of a module directory, relative dirs being relative to
+// the working directory
+func (r *rover) moduleDir(dir string) string {
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	return filepath.Join(r.WorkingDir, dir)
+}
+
+// localModuleLocation resolves the directory of a module with a local source from its
+// parent's directory, for configurations that haven't been initialized
+func (r *rover) localModuleLocation(locations map[string]string, parentKey string, childKey string, source string) (string, bool) {
+	if moduleSourceType(source) != ModuleSourceLocal {
+		return "", false
+	}
+
+	parentDir, ok := locations[parentKey]
+	if !ok {
+		if parentKey != "" {
+			return "", false
+		}
+		parentDir = r.WorkingDir
+	}
+
+	dir := filepath.Join(parentDir, source)
+	locations[childKey] = dir
+
+	if r.ModuleManifest != nil {
+		rel, err := filepath.Rel(r.WorkingDir, dir)
+		if err != nil {
+			rel = dir
+		}
+		r.ModuleManifest[childKey] = ModuleLocation{Key: childKey, Source: source, Dir: rel}
+	}
+
+	return dir, true
 }
 
 func (r *rover) PopulateConfigs(parent string, parentKey string, rso *ResourcesOverview, config *tfjson.ConfigModule) {
@@ -138,15 +266,29 @@ func (r *rover) PopulateConfigs(parent string, parentKey string, rso *ResourcesO
 			childKey = fmt.Sprintf("%s.%s", parentKey, childKey)
 		}
 
-		childPath := ml[childKey]
-		child, _ := tfconfig.LoadModule(childPath)
+		childPath, ok := ml[childKey]
+		if !ok {
+			childPath, ok = r.localModuleLocation(ml, parentKey, childKey, m.Source)
+		}
+
+		var child *tfconfig.Module
+		if ok {
+			child, _ = tfconfig.LoadModule(childPath)
+		}
+
 		// If module can be loaded from filesystem
-		if !child.Diagnostics.HasErrors() {
+		if child != nil && !child.Diagnostics.HasErrors() {
 			rc[mn].Module = child
 		} else {
 			log.Printf("Continuing without loading module from filesystem: %s\n", childKey)
 		}
 
+		if child != nil {
+			rc[mn].Diagnostics = r.configDiagnostics(mn, child.Diagnostics)
+		} else {
+			rc[mn].Diagnostics = []Diagnostic{moduleNotLoaded(mn, m.Source)}
+		}
+
 		rc[mn].ModuleConfig = m
 
 		r.PopulateConfigs(mn, childKey, rso, m.Module)
@@ -162,6 +304,13 @@ func (r *rover) PopulateModuleState(rso *ResourcesOverview, module *tfjson.State
 	for _, rst := range module.Resources {
 		id := rst.Address
 		parent := module.Address
+
+		// Deposed objects share the address of the current object, they are destroyed by
+		// the next apply
+		if rst.DeposedKey != "" {
+			continue
+		}
+
 		//fmt.Printf("ID: %v\n", id)
 		if rst.AttributeValues != nil {
 
@@ -205,11 +354,20 @@ func (r *rover) PopulateModuleState(rso *ResourcesOverview, module *tfjson.State
 
 			//fmt.Printf("%v - %v\n", id, parent)
 			rs[parent].Children[id] = rs[id]
+			rs[id].DependsOn = rst.DependsOn
+
+			var values interface{} = rst.AttributeValues
+			if len(rst.SensitiveValues) > 0 && !r.ShowSensitive {
+				var sensitive interface{}
+				if err := json.Unmarshal(rst.SensitiveValues, &sensitive); err == nil {
+					values = maskSensitive(values, sensitive)
+				}
+			}
 
 			if prior {
-				rs[id].Change.Before = rst.AttributeValues
+				rs[id].Change.Before = values
 			} else {
-				rs[id].Change.After = rst.AttributeValues
+				rs[id].Change.After = values
 			}
 		}
 	}
@@ -260,12 +418,40 @@ func (r *rover) PopulateModuleState(rso *ResourcesOverview, module *tfjson.State
 
 }
 
+// PopulateCurrentState populates rso.States from r.State, as if nothing changes
+func (r *rover) PopulateCurrentState(rso *ResourcesOverview) {
+	rs := rso.States
+
+	rso.Mode = ModeState
+
+	r.PopulateModuleState(rso, r.State.Values.RootModule, true)
+	r.PopulateModuleState(rso, r.State.Values.RootModule, false)
+
+	if _, ok := rs[""]; !ok {
+		rs[""] = &StateOverview{}
+		rs[""].Children = make(map[string]*StateOverview)
+		rs[""].IsParent = false
+		rs[""].Type = ResourceTypeModule
+	}
+
+	for outputName, output := range r.State.Values.Outputs {
+		rs[outputName] = &StateOverview{}
+		rs[outputName].Type = ResourceTypeOutput
+
+		value := output.Value
+		if output.Sensitive && !r.ShowSensitive {
+			value = "Sensitive Value"
+		}
+		rs[outputName].Change.Before = value
+		rs[outputName].Change.After = value
+	}
+}
+
 // GenerateResourceOverview - Overview of files and their resources
 // Groups different resource types together
 func (r *rover) GenerateResourceOverview() error {
 	log.Println("Generating resource overview...")
 
-	matchBrackets := regexp.MustCompile(`\[[^\[\]]*\]`)
 	rso := &ResourcesOverview{}
 
 	rso.Locations = make(map[string]string)
@@ -279,9 +465,33 @@ func (r *rover) GenerateResourceOverview() error {
 	moduleJSONPath := filepath.Join(r.WorkingDir, ".terraform/modules/modules.json")
 	r.PopulateModuleLocations(moduleJSONPath, rso.Locations)
 
+	// Units of a stack (e.g. Terragrunt) are loaded as modules of the root
+	for key, dir := range r.Units {
+		rso.Locations[key] = dir
+	}
+
+	// Binary and version that produced the plan or state
+	rso.Tool = r.Tool
+	rso.ToolVersion = r.ToolVersion
+	if rso.Tool == "" {
+		// Plans and states read from a file, or from Terraform Cloud
+		rso.Tool = detectTool(r.Plan, r.State)
+	}
+	if r.State != nil && r.State.TerraformVersion != "" {
+		rso.ToolVersion = r.State.TerraformVersion
+	} else if r.Plan != nil && r.Plan.TerraformVersion != "" {
+		rso.ToolVersion = r.Plan.TerraformVersion
+	}
+	if rso.Tool != "" {
+		log.Printf("Produced by %s v%s", toolName(rso.Tool), rso.ToolVersion)
+	} else if rso.ToolVersion != "" {
+		log.Printf("Produced by Terraform or OpenTofu v%s", rso.ToolVersion)
+	}
+
 	// Create root module configuration
 	rc[""] = &ConfigOverview{}
 	rootModule, _ := tfconfig.LoadModule(r.WorkingDir)
+	rc[""].Diagnostics = r.configDiagnostics("", rootModule.Diagnostics)
 	// If module can be loaded from filesystem
 	if !rootModule.Diagnostics.HasErrors() {
 		rc[""].Module = rootModule
@@ -291,9 +501,39 @@ func (r *rover) GenerateResourceOverview() error {
 	}
 
 	rc[""].ModuleConfig = &tfjson.ModuleCall{}
-	rc[""].ModuleConfig.Module = r.Plan.Config.RootModule
+	if r.State != nil {
+		rc[""].ModuleConfig.Module = StateConfig(r.State.Values.RootModule)
+	} else {
+		rc[""].ModuleConfig.Module = r.Plan.Config.RootModule
+	}
 
-	r.PopulateConfigs("", "", rso, r.Plan.Config.RootModule)
+	r.PopulateConfigs("", "", rso, rc[""].ModuleConfig.Module)
+	r.PopulateLifecycles(rso)
+
+	// Visualize current state without a plan
+	if r.State != nil {
+		r.PopulateCurrentState(rso)
+		r.PopulateSchemas(rso)
+		r.PopulateCosts(rso)
+		r.PopulateCompliance(rso)
+
+		// Copied, PopulateDiagnostics appends to it
+		rso.Diagnostics = append([]Diagnostic{}, r.Diagnostics...)
+		r.PopulateDiagnostics(rso)
+
+		r.RSO = rso
+		return nil
+	}
+
+	rso.Mode = ModePlan
+	rso.PlanMode = r.PlanMode
+	if rso.PlanMode == "" {
+		rso.PlanMode = inferPlanMode(r.Plan, r.ChangeSummary, r.PlanHasDrift || len(r.ResourceDrift) > 0)
+	}
+	if r.PriorStatePath != "" {
+		rso.Mode = ModeStateDiff
+		rso.PlanMode = ""
+	}
 
 	// Populate prior state
 	if r.Plan.PriorState != nil {
@@ -373,6 +613,13 @@ func (r *rover) GenerateResourceOverview() error {
 				rs[parent].Children[id] = rs[id]
 			}
 			rs[id].Change = *resource.Change
+			if detail, ok := r.ChangeDetails[resource]; ok {
+				rs[id].PreviousAddress = detail.PreviousAddress
+				rs[id].Importing = detail.Importing
+				if resource.Change.Actions.Replace() {
+					rs[id].Replacement = r.explainReplacement(rso, resource, detail)
+				}
+			}
 
 			// Create resource config if doesn't exist
 			if _, ok := rc[configId]; !ok {
@@ -383,11 +630,38 @@ func (r *rover) GenerateResourceOverview() error {
 				// TODO: Find long term fix
 				rc[configId].ResourceConfig.Name = resource.Name
 				rc[configId].ResourceConfig.Type = resource.Type
+				rc[configId].ResourceConfig.Mode = resource.Mode
 			}
 
 		}
 	}
 
+	r.PopulateDeferred(rso)
+	r.PopulateSchemas(rso)
+	r.PopulateCosts(rso)
+	r.PopulateCompliance(rso)
+
+	// Loop through resource drift (changes made outside of Terraform)
+	for _, resource := range r.ResourceDrift {
+		if _, ok := rs[resource.Address]; ok && resource.Change != nil {
+			rs[resource.Address].Drift = resource.Change
+		}
+	}
+
+	rso.Diagnostics = append(append([]Diagnostic{}, r.Diagnostics...), r.LifecycleDiagnostics(rso)...)
+	r.PopulateDiagnostics(rso)
+	// Copied, failed checks are added to it
+	if r.ChangeSummary != nil {
+		summary := *r.ChangeSummary
+		rso.Summary = &summary
+	}
+	if rso.Summary == nil && r.Plan != nil && r.PriorStatePath == "" {
+		rso.Summary = r.PlanSummary()
+	}
+
+	r.PopulateChecks(rso)
+	summarizeCost(rso)
+
 	r.RSO = rso
 
 	return nil
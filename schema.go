@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Attribute is the provider schema of a resource attribute
+type Attribute struct {
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Optional    bool   `json:"optional,omitempty"`
+	Computed    bool   `json:"computed,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// getProviderSchemas reads the provider schemas from -providerSchemaPath, or runs
+// terraform providers schema -json with -providerSchema
+func (r *rover) getProviderSchemas() error {
+	if r.SchemaPath != "" {
+		log.Println("Using provided provider schemas...")
+
+		schemaJSON, err := ioutil.ReadFile(r.SchemaPath)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to read provider schemas (%s): %s", r.SchemaPath, err))
+		}
+
+		r.Schemas = &tfjson.ProviderSchemas{}
+		if err := json.Unmarshal(schemaJSON, r.Schemas); err != nil {
+			return errors.New(fmt.Sprintf("Unable to read provider schemas (%s): %s", r.SchemaPath, err))
+		}
+		return nil
+	}
+
+	if !r.FetchSchema {
+		return nil
+	}
+
+	log.Println("Reading provider schemas...")
+
+	tf, err := r.newTerraform(r.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	r.Schemas, err = tf.ProvidersSchema(context.Background())
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to read provider schemas: %s", err))
+	}
+
+	return nil
+}
+
+// resourceSchema returns the schema of a resource type from the provider of the resource,
+// e.g. registry.terraform.io/hashicorp/aws, and the address of that provider. If the provider
+// is unknown or has no schema, the first provider with the resource type is used.
+func (r *rover) resourceSchema(mode tfjson.ResourceMode, resourceType string, provider string) (*tfjson.Schema, string) {
+	if r.Schemas == nil {
+		return nil, ""
+	}
+
+	lookup := func(ps *tfjson.ProviderSchema) *tfjson.Schema {
+		if ps == nil {
+			return nil
+		}
+		if mode == tfjson.DataResourceMode {
+			return ps.DataSourceSchemas[resourceType]
+		}
+		return ps.ResourceSchemas[resourceType]
+	}
+
+	if schema := lookup(r.Schemas.Schemas[provider]); schema != nil {
+		return schema, provider
+	}
+
+	providers := []string{}
+	for p := range r.Schemas.Schemas {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+
+	for _, p := range providers {
+		if schema := lookup(r.Schemas.Schemas[p]); schema != nil {
+			return schema, p
+		}
+	}
+
+	return nil, ""
+}
+
+// resourceMode returns the mode of a resource from its address, for configurations built
+// from resource changes without one
+func resourceMode(address string) tfjson.ResourceMode {
+	parts := strings.Split(matchBrackets.ReplaceAllString(address, ""), ".")
+
+	// Skip module.<name> steps, a module may be called "data"
+	i := 0
+	for i+1 < len(parts) && parts[i] == "module" {
+		i += 2
+	}
+	if i < len(parts) && parts[i] == "data" {
+		return tfjson.DataResourceMode
+	}
+	return tfjson.ManagedResourceMode
+}
+
+// schemaAttributePath returns the schema attribute of a replace path, without the indexes
+// of nested blocks, e.g. ebs_block_device[0].volume_size is ebs_block_device.volume_size
+func schemaAttributePath(path string) string {
+	return matchBrackets.ReplaceAllString(path, "")
+}
+
+// schemaAttributes flattens the attributes of a schema block, with nested block
+// attributes prefixed by the block name
+func schemaAttributes(block *tfjson.SchemaBlock, prefix string, attributes map[string]*Attribute) {
+	if block == nil {
+		return
+	}
+
+	for name, a := range block.Attributes {
+		attribute := &Attribute{
+			Description: a.Description,
+			Required:    a.Required,
+			Optional:    a.Optional,
+			Computed:    a.Computed,
+			Sensitive:   a.Sensitive,
+			Deprecated:  a.Deprecated,
+		}
+		if a.AttributeType != cty.NilType {
+			attribute.Type = a.AttributeType.FriendlyName()
+		} else if a.AttributeNestedType != nil {
+			attribute.Type = string(a.AttributeNestedType.NestingMode)
+		}
+		attributes[prefix+name] = attribute
+	}
+
+	for name, nested := range block.NestedBlocks {
+		schemaAttributes(nested.Block, fmt.Sprintf("%s%s.", prefix, name), attributes)
+	}
+}
+
+// docsURL returns the registry documentation page of a resource type
+func docsURL(provider string, mode tfjson.ResourceMode, resourceType string) string {
+	// registry.terraform.io/hashicorp/aws
+	parts := strings.Split(provider, "/")
+	if len(parts) != 3 {
+		return ""
+	}
+	host, namespace, name := parts[0], parts[1], parts[2]
+
+	kind := "resources"
+	if mode == tfjson.DataResourceMode {
+		kind = "data-sources"
+	}
+	page := strings.TrimPrefix(resourceType, fmt.Sprintf("%s_", name))
+
+	if host == "registry.opentofu.org" {
+		return fmt.Sprintf("https://search.opentofu.org/provider/%s/%s/latest/docs/%s/%s", namespace, name, kind, page)
+	}
+	return fmt.Sprintf("https://registry.terraform.io/providers/%s/%s/latest/docs/%s/%s", namespace, name, kind, page)
+}
+
+// PopulateSchemas adds the provider schema of each resource's attributes and its
+// documentation link to its configuration. The replace paths of each replaced instance
+// are matched to their schema attribute.
+func (r *rover) PopulateSchemas(rso *ResourcesOverview) {
+	if r.Schemas == nil {
+		return
+	}
+
+	providers := r.resourceProviders()
+
+	for configId, config := range rso.Configs {
+		resource := config.ResourceConfig
+		if resource == nil {
+			continue
+		}
+
+		mode := resource.Mode
+		if mode == "" {
+			mode = resourceMode(configId)
+		}
+
+		schema, provider := r.resourceSchema(mode, resource.Type, providers[configId])
+		if schema == nil {
+			continue
+		}
+
+		config.Attributes = map[string]*Attribute{}
+		schemaAttributes(schema.Block, "", config.Attributes)
+		config.DocsURL = docsURL(provider, mode, resource.Type)
+	}
+
+	for id, state := range rso.States {
+		if state.Replacement == nil {
+			continue
+		}
+
+		config, ok := rso.Configs[matchBrackets.ReplaceAllString(id, "")]
+		if !ok || config.Attributes == nil {
+			continue
+		}
+
+		// Set on the instance, other instances of the resource may be replaced for other attributes
+		for i, replaced := range state.Replacement.Attributes {
+			if path := schemaAttributePath(replaced.Path); config.Attributes[path] != nil {
+				state.Replacement.Attributes[i].Attribute = path
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testSchema(description string) *tfjson.Schema {
+	return &tfjson.Schema{Block: &tfjson.SchemaBlock{
+		Attributes: map[string]*tfjson.SchemaAttribute{
+			"ami": {AttributeType: cty.String, Required: true, Description: description},
+		},
+	}}
+}
+
+func TestResourceSchema(t *testing.T) {
+	r := &rover{Schemas: &tfjson.ProviderSchemas{Schemas: map[string]*tfjson.ProviderSchema{
+		"registry.terraform.io/hashicorp/aws": {
+			ResourceSchemas:   map[string]*tfjson.Schema{"aws_instance": testSchema("hashicorp")},
+			DataSourceSchemas: map[string]*tfjson.Schema{"aws_ami": testSchema("hashicorp data source")},
+		},
+		"registry.terraform.io/acme/aws": {
+			ResourceSchemas: map[string]*tfjson.Schema{"aws_instance": testSchema("acme")},
+		},
+		"registry.terraform.io/hashicorp/random": {
+			ResourceSchemas: map[string]*tfjson.Schema{"random_id": testSchema("random")},
+		},
+	}}}
+
+	tests := []struct {
+		name         string
+		mode         tfjson.ResourceMode
+		resourceType string
+		provider     string
+		description  string
+		wantProvider string
+	}{
+		{"own provider", tfjson.ManagedResourceMode, "aws_instance", "registry.terraform.io/acme/aws", "acme", "registry.terraform.io/acme/aws"},
+		{"other own provider", tfjson.ManagedResourceMode, "aws_instance", "registry.terraform.io/hashicorp/aws", "hashicorp", "registry.terraform.io/hashicorp/aws"},
+		{"unknown provider falls back in order", tfjson.ManagedResourceMode, "aws_instance", "", "acme", "registry.terraform.io/acme/aws"},
+		{"provider without the type falls back", tfjson.ManagedResourceMode, "random_id", "registry.terraform.io/hashicorp/aws", "random", "registry.terraform.io/hashicorp/random"},
+		{"data source", tfjson.DataResourceMode, "aws_ami", "registry.terraform.io/hashicorp/aws", "hashicorp data source", "registry.terraform.io/hashicorp/aws"},
+		{"data source isn't a resource", tfjson.ManagedResourceMode, "aws_ami", "registry.terraform.io/hashicorp/aws", "", ""},
+		{"missing", tfjson.ManagedResourceMode, "google_compute_instance", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, provider := r.resourceSchema(tt.mode, tt.resourceType, tt.provider)
+			if provider != tt.wantProvider {
+				t.Errorf("provider = %q, want %q", provider, tt.wantProvider)
+			}
+			description := ""
+			if schema != nil {
+				description = schema.Block.Attributes["ami"].Description
+			}
+			if description != tt.description {
+				t.Errorf("schema description = %q, want %q", description, tt.description)
+			}
+		})
+	}
+}
+
+func TestSchemaAttributes(t *testing.T) {
+	block := &tfjson.SchemaBlock{
+		Attributes: map[string]*tfjson.SchemaAttribute{
+			"id":       {AttributeType: cty.String, Computed: true},
+			"tags":     {AttributeType: cty.Map(cty.String), Optional: true},
+			"password": {AttributeType: cty.String, Sensitive: true, Required: true},
+			"old":      {AttributeType: cty.Bool, Deprecated: true, Optional: true},
+			"rules":    {AttributeNestedType: &tfjson.SchemaNestedAttributeType{NestingMode: tfjson.SchemaNestingModeList}},
+		},
+		NestedBlocks: map[string]*tfjson.SchemaBlockType{
+			"ebs_block_device": {Block: &tfjson.SchemaBlock{
+				Attributes: map[string]*tfjson.SchemaAttribute{
+					"volume_size": {AttributeType: cty.Number, Optional: true, Description: "Size in GiB"},
+				},
+			}},
+		},
+	}
+
+	want := map[string]*Attribute{
+		"id":                           {Type: "string", Computed: true},
+		"tags":                         {Type: "map of string", Optional: true},
+		"password":                     {Type: "string", Sensitive: true, Required: true},
+		"old":                          {Type: "bool", Deprecated: true, Optional: true},
+		"rules":                        {Type: "list"},
+		"ebs_block_device.volume_size": {Type: "number", Optional: true, Description: "Size in GiB"},
+	}
+
+	attributes := map[string]*Attribute{}
+	schemaAttributes(block, "", attributes)
+	if !reflect.DeepEqual(attributes, want) {
+		for name, attribute := range attributes {
+			t.Logf("%s: %+v", name, attribute)
+		}
+		t.Errorf("schemaAttributes() didn't match")
+	}
+}
+
+func TestSchemaAttributePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"ami", "ami"},
+		{"ebs_block_device[0].volume_size", "ebs_block_device.volume_size"},
+		{`tags["owner"]`, "tags"},
+		{"network_interface[1].access_config[0].nat_ip", "network_interface.access_config.nat_ip"},
+	}
+
+	for _, tt := range tests {
+		if got := schemaAttributePath(tt.path); got != tt.want {
+			t.Errorf("schemaAttributePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResourceMode(t *testing.T) {
+	tests := []struct {
+		address string
+		want    tfjson.ResourceMode
+	}{
+		{"aws_instance.web", tfjson.ManagedResourceMode},
+		{"data.aws_ami.ubuntu", tfjson.DataResourceMode},
+		{"module.app.data.aws_ami.ubuntu", tfjson.DataResourceMode},
+		{"module.data.aws_instance.web", tfjson.ManagedResourceMode},
+		{`module.app["a.b"].data.aws_ami.ubuntu`, tfjson.DataResourceMode},
+	}
+
+	for _, tt := range tests {
+		if got := resourceMode(tt.address); got != tt.want {
+			t.Errorf("resourceMode(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestPopulateSchemas(t *testing.T) {
+	r := &rover{
+		Schemas: &tfjson.ProviderSchemas{Schemas: map[string]*tfjson.ProviderSchema{
+			"registry.terraform.io/hashicorp/aws": {
+				ResourceSchemas: map[string]*tfjson.Schema{"aws_instance": {Block: &tfjson.SchemaBlock{
+					Attributes: map[string]*tfjson.SchemaAttribute{
+						"ami": {AttributeType: cty.String, Required: true},
+					},
+					NestedBlocks: map[string]*tfjson.SchemaBlockType{
+						"ebs_block_device": {Block: &tfjson.SchemaBlock{Attributes: map[string]*tfjson.SchemaAttribute{
+							"volume_size": {AttributeType: cty.Number, Optional: true},
+						}}},
+					},
+				}}},
+				DataSourceSchemas: map[string]*tfjson.Schema{"aws_ami": testSchema("data source")},
+			},
+		}},
+		Plan: &tfjson.Plan{ResourceChanges: []*tfjson.ResourceChange{
+			{Address: "aws_instance.web[0]", ProviderName: "registry.terraform.io/hashicorp/aws"},
+			{Address: "data.aws_ami.ubuntu", ProviderName: "registry.terraform.io/hashicorp/aws"},
+		}},
+	}
+
+	// Configs built from resource changes have no mode
+	rso := &ResourcesOverview{
+		Configs: map[string]*ConfigOverview{
+			"aws_instance.web":    {ResourceConfig: &tfjson.ConfigResource{Type: "aws_instance"}},
+			"data.aws_ami.ubuntu": {ResourceConfig: &tfjson.ConfigResource{Type: "aws_ami"}},
+		},
+		States: map[string]*StateOverview{
+			"aws_instance.web[0]": {Replacement: &Replacement{Attributes: []ReplacementAttribute{
+				{Path: "ebs_block_device[0].volume_size"},
+				{Path: "user_data"},
+			}}},
+			"aws_instance.web[1]": {Replacement: &Replacement{Attributes: []ReplacementAttribute{{Path: "ami"}}}},
+		},
+	}
+
+	r.PopulateSchemas(rso)
+
+	if rso.Configs["data.aws_ami.ubuntu"].Attributes["ami"] == nil {
+		t.Error("expected the data source schema for data.aws_ami.ubuntu")
+	}
+	if url := rso.Configs["data.aws_ami.ubuntu"].DocsURL; url != "https://registry.terraform.io/providers/hashicorp/aws/latest/docs/data-sources/ami" {
+		t.Errorf("data source docs URL = %q", url)
+	}
+
+	want := []ReplacementAttribute{{Path: "ebs_block_device[0].volume_size", Attribute: "ebs_block_device.volume_size"}, {Path: "user_data"}}
+	if got := rso.States["aws_instance.web[0]"].Replacement.Attributes; !reflect.DeepEqual(got, want) {
+		t.Errorf("aws_instance.web[0] replacement attributes = %+v, want %+v", got, want)
+	}
+	want = []ReplacementAttribute{{Path: "ami", Attribute: "ami"}}
+	if got := rso.States["aws_instance.web[1]"].Replacement.Attributes; !reflect.DeepEqual(got, want) {
+		t.Errorf("aws_instance.web[1] replacement attributes = %+v, want %+v", got, want)
+	}
+}
@@ -24,6 +24,9 @@ func (ro *rover) startServer(ipPort string, frontendFS http.Handler) error {
 		w.Header().Set("Content-Type", "application/json")
 		io.WriteString(w, `{"alive": true}`)
 	})
+	if ro.Apply != nil {
+		m.Handle("/api/apply/events", ro.Apply)
+	}
 	m.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
 		fileType := strings.Replace(r.URL.Path, "/api/", "", 1)
 
@@ -53,8 +56,79 @@ func (ro *rover) startServer(ipPort string, frontendFS http.Handler) error {
 			if err != nil {
 				io.WriteString(w, fmt.Sprintf("Error producing graph JSON: %s\n", err))
 			}
+		case "apply":
+			if ro.Apply == nil {
+				io.WriteString(w, "Apply visualization is not enabled, use -apply or -applyLogPath\n")
+				break
+			}
+			j, err = json.Marshal(ro.Apply.Overview())
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing apply JSON: %s\n", err))
+			}
+		case "providers":
+			j, err = json.Marshal(ro.Providers)
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing providers JSON: %s\n", err))
+			}
+		case "diagnostics":
+			j, err = json.Marshal(ro.RSO.Diagnostics)
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing diagnostics JSON: %s\n", err))
+			}
+		case "findings":
+			if ro.Findings == nil {
+				io.WriteString(w, "No findings, use -findings with a tflint, tfsec, trivy, checkov or SARIF report\n")
+				break
+			}
+			j, err = json.Marshal(ro.Findings)
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing findings JSON: %s\n", err))
+			}
+		case "compliance":
+			if ro.Compliance == nil {
+				io.WriteString(w, "Compliance checks are not enabled, use -complianceRules\n")
+				break
+			}
+			j, err = json.Marshal(ro.Compliance)
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing compliance JSON: %s\n", err))
+			}
+		case "lifecycle":
+			// e.g. /api/lifecycle?setting=prevent_destroy,create_before_destroy
+			settings := []string{}
+			for _, setting := range r.URL.Query()["setting"] {
+				for _, s := range strings.Split(setting, ",") {
+					if s != "" {
+						settings = append(settings, s)
+					}
+				}
+			}
+			resources, lerr := ro.LifecycleResources(settings)
+			if lerr != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, fmt.Sprintf("%s\n", lerr))
+				break
+			}
+			j, err = json.Marshal(resources)
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing lifecycle JSON: %s\n", err))
+			}
+		case "modules":
+			j, err = json.Marshal(ro.Modules)
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing modules JSON: %s\n", err))
+			}
+		case "environments":
+			if ro.EnvMatrix == nil {
+				io.WriteString(w, "Environment comparison is not enabled, use -allWorkspaces or -environment\n")
+				break
+			}
+			j, err = json.Marshal(ro.EnvMatrix)
+			if err != nil {
+				io.WriteString(w, fmt.Sprintf("Error producing environments JSON: %s\n", err))
+			}
 		default:
-			io.WriteString(w, "Please enter a valid file type: plan, rso, map, graph\n")
+			io.WriteString(w, "Please enter a valid file type: plan, rso, map, graph, apply, providers, modules, lifecycle, diagnostics, findings, compliance, environments\n")
 		}
 
 		w.Header().Set("Content-Type", "application/json")
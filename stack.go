@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// StackUnit is an independently planned configuration that is part of a larger stack,
+// e.g. a Terragrunt unit. Each unit is visualized as a module of the stack root.
+type StackUnit struct {
+	Key       string
+	Dir       string
+	Source    string
+	Plan      *tfjson.Plan
+	Checks    []*CheckResult
+	Deferred  []*DeferredChange
+	DependsOn []string
+}
+
+// stackUnitKey turns a path into a valid module name
+func stackUnitKey(path string) string {
+	invalidChars := regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	return invalidChars.ReplaceAllString(path, "_")
+}
+
+// stackUnitKeys turns paths into unique module names, keyed by path. Paths with the same
+// name, e.g. a/b and a_b, get a numbered suffix in path order.
+func stackUnitKeys(paths []string) map[string]string {
+	sorted := append([]string{}, paths...)
+	sort.Strings(sorted)
+
+	keys := map[string]string{}
+	taken := map[string]bool{}
+	for _, path := range sorted {
+		base := stackUnitKey(path)
+		key := base
+		for i := 2; taken[key]; i++ {
+			key = fmt.Sprintf("%s_%d", base, i)
+		}
+		if key != base {
+			log.Printf("Unit %s has the same name as another unit, using %s...\n", path, key)
+		}
+
+		taken[key] = true
+		keys[path] = key
+	}
+
+	return keys
+}
+
+// CombineStackUnits nests the plan of each unit as a module call of a single plan,
+// so the stack can be visualized with the regular overview, map and graph
+func CombineStackUnits(units []*StackUnit) *tfjson.Plan {
+	sort.Slice(units, func(i, j int) bool {
+		return units[i].Key < units[j].Key
+	})
+
+	plan := &tfjson.Plan{
+		Config: &tfjson.Config{
+			RootModule: &tfjson.ConfigModule{
+				ModuleCalls: map[string]*tfjson.ModuleCall{},
+			},
+		},
+		PriorState: &tfjson.State{
+			Values: &tfjson.StateValues{
+				RootModule: &tfjson.StateModule{},
+			},
+		},
+		PlannedValues: &tfjson.StateValues{
+			RootModule: &tfjson.StateModule{},
+		},
+		OutputChanges: map[string]*tfjson.Change{},
+	}
+
+	for _, unit := range units {
+		prefix := fmt.Sprintf("module.%s", unit.Key)
+
+		if plan.TerraformVersion == "" {
+			plan.TerraformVersion = unit.Plan.TerraformVersion
+		}
+		if plan.FormatVersion == "" {
+			plan.FormatVersion = unit.Plan.FormatVersion
+		}
+
+		// Configuration
+		moduleCall := &tfjson.ModuleCall{
+			Source:      unit.Source,
+			Module:      &tfjson.ConfigModule{},
+			Expressions: map[string]*tfjson.Expression{},
+		}
+		if unit.Plan.Config != nil && unit.Plan.Config.RootModule != nil {
+			moduleCall.Module = unit.Plan.Config.RootModule
+		}
+
+		// Dependencies between units are drawn as references between modules
+		for _, dep := range unit.DependsOn {
+			moduleCall.Expressions[fmt.Sprintf("dependency.%s", dep)] = &tfjson.Expression{
+				ExpressionData: &tfjson.ExpressionData{
+					References: []string{fmt.Sprintf("module.%s", dep)},
+				},
+			}
+		}
+
+		plan.Config.RootModule.ModuleCalls[unit.Key] = moduleCall
+
+		// State
+		if unit.Plan.PriorState != nil && unit.Plan.PriorState.Values != nil && unit.Plan.PriorState.Values.RootModule != nil {
+			prefixStateModule(unit.Plan.PriorState.Values.RootModule, prefix)
+			plan.PriorState.Values.RootModule.ChildModules = append(plan.PriorState.Values.RootModule.ChildModules, unit.Plan.PriorState.Values.RootModule)
+		}
+
+		if unit.Plan.PlannedValues != nil && unit.Plan.PlannedValues.RootModule != nil {
+			prefixStateModule(unit.Plan.PlannedValues.RootModule, prefix)
+			plan.PlannedValues.RootModule.ChildModules = append(plan.PlannedValues.RootModule.ChildModules, unit.Plan.PlannedValues.RootModule)
+		}
+
+		// Changes
+		for _, rc := range unit.Plan.ResourceChanges {
+			prefixResourceChange(rc, prefix)
+			plan.ResourceChanges = append(plan.ResourceChanges, rc)
+		}
+
+		for _, deferred := range unit.Deferred {
+			if deferred.ResourceChange != nil {
+				prefixResourceChange(deferred.ResourceChange, prefix)
+			}
+		}
+
+		for name, oc := range unit.Plan.OutputChanges {
+			plan.OutputChanges[fmt.Sprintf("%s.output.%s", prefix, name)] = oc
+		}
+
+		// Checks
+		for _, result := range unit.Checks {
+			prefixCheckAddress(&result.Address, prefix)
+			for i := range result.Instances {
+				prefixCheckAddress(&result.Instances[i].Address, prefix)
+			}
+		}
+	}
+
+	return plan
+}
+
+// combineStackUnits sets the plan, checks and deferred changes of the stack from those
+// of its units
+func (r *rover) combineStackUnits(units []*StackUnit) {
+	r.Plan = CombineStackUnits(units)
+
+	r.Checks = nil
+	r.Deferred = nil
+	for _, unit := range units {
+		r.Checks = append(r.Checks, unit.Checks...)
+		r.Deferred = append(r.Deferred, unit.Deferred...)
+	}
+}
+
+// prefixResourceChange moves a resource change under the module address prefix
+func prefixResourceChange(rc *tfjson.ResourceChange, prefix string) {
+	rc.Address = fmt.Sprintf("%s.%s", prefix, rc.Address)
+	if rc.ModuleAddress == "" {
+		rc.ModuleAddress = prefix
+	} else {
+		rc.ModuleAddress = fmt.Sprintf("%s.%s", prefix, rc.ModuleAddress)
+	}
+}
+
+// prefixCheckAddress moves the address of a checkable object under the module address prefix
+func prefixCheckAddress(address *CheckAddress, prefix string) {
+	address.ToDisplay = fmt.Sprintf("%s.%s", prefix, address.ToDisplay)
+	if address.Module == "" {
+		address.Module = prefix
+	} else {
+		address.Module = fmt.Sprintf("%s.%s", prefix, address.Module)
+	}
+}
+
+// prefixStateModule moves a root state module under the module address prefix
+func prefixStateModule(module *tfjson.StateModule, prefix string) {
+	if module.Address == "" {
+		module.Address = prefix
+	} else {
+		module.Address = fmt.Sprintf("%s.%s", prefix, module.Address)
+	}
+
+	for _, rst := range module.Resources {
+		rst.Address = fmt.Sprintf("%s.%s", prefix, rst.Address)
+	}
+
+	for _, childModule := range module.ChildModules {
+		prefixStateModule(childModule, prefix)
+	}
+}
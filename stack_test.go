@@ -0,0 +1,252 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func testUnitPlan(version string, addresses ...string) *tfjson.Plan {
+	plan := &tfjson.Plan{
+		FormatVersion:    "1.2",
+		TerraformVersion: version,
+		Config: &tfjson.Config{
+			RootModule: &tfjson.ConfigModule{},
+		},
+		PriorState: &tfjson.State{
+			Values: &tfjson.StateValues{
+				RootModule: &tfjson.StateModule{},
+			},
+		},
+		PlannedValues: &tfjson.StateValues{
+			RootModule: &tfjson.StateModule{},
+		},
+		OutputChanges: map[string]*tfjson.Change{
+			"id": {Actions: tfjson.Actions{tfjson.ActionCreate}},
+		},
+	}
+
+	for _, address := range addresses {
+		moduleAddress := ""
+		if strings.HasPrefix(address, "module.child.") {
+			moduleAddress = "module.child"
+		}
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address:       address,
+			ModuleAddress: moduleAddress,
+			Change:        &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionCreate}},
+		})
+		plan.PlannedValues.RootModule.Resources = append(plan.PlannedValues.RootModule.Resources, &tfjson.StateResource{
+			Address: address,
+		})
+	}
+
+	return plan
+}
+
+func TestStackUnitKey(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"vpc", "vpc"},
+		{"envs/prod/vpc", "envs_prod_vpc"},
+		{"../shared stack", "_shared_stack"},
+		{"app-1", "app-1"},
+	}
+
+	for _, tt := range tests {
+		if got := stackUnitKey(tt.path); got != tt.want {
+			t.Errorf("stackUnitKey(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCombineStackUnits(t *testing.T) {
+	units := []*StackUnit{
+		{
+			Key:    "app",
+			Source: "./app",
+			Plan:   testUnitPlan("1.6.0", "aws_instance.web", "module.child.aws_s3_bucket.logs"),
+			Checks: []*CheckResult{{
+				Address: CheckAddress{Kind: CheckKindResource, ToDisplay: "module.child.aws_s3_bucket.logs", Module: "module.child"},
+				Status:  CheckFail,
+				Instances: []CheckInstance{{
+					Address: CheckAddress{Kind: CheckKindResource, ToDisplay: "module.child.aws_s3_bucket.logs", Module: "module.child"},
+					Status:  CheckFail,
+				}},
+			}},
+			DependsOn: []string{"vpc"},
+		},
+		{
+			Key:    "vpc",
+			Source: "./vpc",
+			Plan:   testUnitPlan("1.5.0", "aws_vpc.main"),
+			Checks: []*CheckResult{{
+				Address: CheckAddress{Kind: CheckKindOutput, ToDisplay: "output.id"},
+				Status:  CheckPass,
+			}},
+			Deferred: []*DeferredChange{{
+				Reason:         DeferredInstanceCountUnknown,
+				ResourceChange: testResourceChange("aws_subnet.private", tfjson.ActionCreate),
+			}},
+		},
+	}
+
+	r := &rover{}
+	r.combineStackUnits(units)
+	plan := r.Plan
+
+	if plan.TerraformVersion != "1.6.0" || plan.FormatVersion != "1.2" {
+		t.Errorf("versions = %s %s, want those of the first unit", plan.TerraformVersion, plan.FormatVersion)
+	}
+
+	tests := []struct {
+		address string
+		module  string
+	}{
+		{"module.app.aws_instance.web", "module.app"},
+		{"module.app.module.child.aws_s3_bucket.logs", "module.app.module.child"},
+		{"module.vpc.aws_vpc.main", "module.vpc"},
+	}
+	if len(plan.ResourceChanges) != len(tests) {
+		t.Fatalf("resource changes = %d, want %d", len(plan.ResourceChanges), len(tests))
+	}
+	for i, tt := range tests {
+		rc := plan.ResourceChanges[i]
+		if rc.Address != tt.address || rc.ModuleAddress != tt.module {
+			t.Errorf("change %d = %s (%s), want %s (%s)", i, rc.Address, rc.ModuleAddress, tt.address, tt.module)
+		}
+	}
+
+	calls := plan.Config.RootModule.ModuleCalls
+	if calls["app"] == nil || calls["app"].Source != "./app" || calls["vpc"] == nil {
+		t.Fatalf("module calls = %v, want app and vpc", calls)
+	}
+	references := calls["app"].Expressions["dependency.vpc"].References
+	if !reflect.DeepEqual(references, []string{"module.vpc"}) {
+		t.Errorf("app dependency references = %q, want module.vpc", references)
+	}
+
+	planned := plan.PlannedValues.RootModule.ChildModules
+	if len(planned) != 2 || planned[0].Address != "module.app" || planned[1].Address != "module.vpc" {
+		t.Fatalf("planned modules = %v, want module.app and module.vpc", planned)
+	}
+	if address := planned[1].Resources[0].Address; address != "module.vpc.aws_vpc.main" {
+		t.Errorf("planned resource = %s, want module.vpc.aws_vpc.main", address)
+	}
+
+	for _, name := range []string{"module.app.output.id", "module.vpc.output.id"} {
+		if _, ok := plan.OutputChanges[name]; !ok {
+			t.Errorf("output %s missing", name)
+		}
+	}
+
+	checks := []CheckAddress{
+		{Kind: CheckKindResource, ToDisplay: "module.app.module.child.aws_s3_bucket.logs", Module: "module.app.module.child"},
+		{Kind: CheckKindOutput, ToDisplay: "module.vpc.output.id", Module: "module.vpc"},
+	}
+	if len(r.Checks) != len(checks) {
+		t.Fatalf("checks = %d, want %d", len(r.Checks), len(checks))
+	}
+	for i, want := range checks {
+		if r.Checks[i].Address != want {
+			t.Errorf("check %d = %+v, want %+v", i, r.Checks[i].Address, want)
+		}
+	}
+	if instance := r.Checks[0].Instances[0].Address; instance != checks[0] {
+		t.Errorf("check instance = %+v, want %+v", instance, checks[0])
+	}
+
+	if len(r.Deferred) != 1 {
+		t.Fatalf("deferred changes = %d, want 1", len(r.Deferred))
+	}
+	if rc := r.Deferred[0].ResourceChange; rc.Address != "module.vpc.aws_subnet.private" || rc.ModuleAddress != "module.vpc" {
+		t.Errorf("deferred change = %s (%s), want module.vpc.aws_subnet.private (module.vpc)", rc.Address, rc.ModuleAddress)
+	}
+	if summary := r.PlanSummary(); summary.Deferred != 1 {
+		t.Errorf("summary deferred = %d, want 1", summary.Deferred)
+	}
+}
+
+func TestStackUnitKeys(t *testing.T) {
+	keys := stackUnitKeys([]string{"a_b", "a.b", "a/b", "vpc"})
+
+	want := map[string]string{
+		"a.b": "a_b",
+		"a/b": "a_b_2",
+		"a_b": "a_b_3",
+		"vpc": "vpc",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("stackUnitKeys() = %v, want %v", keys, want)
+	}
+}
+
+func TestTerragruntDependencies(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"root.hcl":                `locals {}`,
+		"prod/terragrunt.hcl":     `include "root" { path = find_in_parent_folders("root.hcl") }`,
+		"prod/vpc/terragrunt.hcl": `include "root" { path = find_in_parent_folders() }`,
+		"prod/db/terragrunt.hcl":  `dependency "vpc" { config_path = "../vpc" }`,
+		"prod/app/terragrunt.hcl": `
+dependency "vpc" {
+  config_path = "${get_parent_terragrunt_dir()}/vpc"
+}
+dependency "db" {
+  config_path = "${get_terragrunt_dir()}/../db"
+}
+dependency "shared" {
+  config_path = "${dirname(find_in_parent_folders("root.hcl"))}/shared"
+}
+dependency "missing" {
+  config_path = "${dirname(find_in_parent_folders("missing.hcl"))}/shared"
+}
+dependency "fallback" {
+  config_path = dirname(find_in_parent_folders("missing.hcl", "${get_terragrunt_dir()}/../cache/x"))
+}
+dependencies {
+  paths = ["../vpc", "${get_original_terragrunt_dir()}/../db"]
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		unit string
+		want []string
+	}{
+		{"prod/vpc", []string{}},
+		{"prod/db", []string{"prod/vpc"}},
+		{"prod/app", []string{"prod/vpc", "prod/db", "shared", "prod/cache", "prod/vpc", "prod/db"}},
+	}
+
+	for _, tt := range tests {
+		deps, err := TerragruntDependencies(filepath.Join(root, tt.unit))
+		if err != nil {
+			t.Fatalf("TerragruntDependencies(%s) error = %v", tt.unit, err)
+		}
+
+		got := []string{}
+		for _, dep := range deps {
+			rel, _ := filepath.Rel(root, dep)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TerragruntDependencies(%s) = %q, want %q", tt.unit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// For parsing .tfstate files (state format version 4)
+type rawState struct {
+	Version          int                       `json:"version"`
+	TerraformVersion string                    `json:"terraform_version"`
+	Outputs          map[string]rawStateOutput `json:"outputs"`
+	Resources        []rawStateResource        `json:"resources"`
+}
+
+type rawStateOutput struct {
+	Value     interface{} `json:"value"`
+	Sensitive bool        `json:"sensitive"`
+}
+
+type rawStateResource struct {
+	Module    string                     `json:"module"`
+	Mode      string                     `json:"mode"`
+	Type      string                     `json:"type"`
+	Name      string                     `json:"name"`
+	Provider  string                     `json:"provider"`
+	Instances []rawStateResourceInstance `json:"instances"`
+}
+
+type rawStateResourceInstance struct {
+	IndexKey            interface{}            `json:"index_key"`
+	SchemaVersion       uint64                 `json:"schema_version"`
+	Attributes          map[string]interface{} `json:"attributes"`
+	SensitiveAttributes [][]rawStatePathStep   `json:"sensitive_attributes"`
+	Status              string                 `json:"status"`
+	Deposed             string                 `json:"deposed"`
+	Dependencies        []string               `json:"dependencies"`
+}
+
+// rawStatePathStep is a step of the path of a sensitive attribute, e.g. get_attr password
+// or index {"value": 0, "type": "number"}
+type rawStatePathStep struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// sensitiveValues converts the paths of sensitive attributes to the sensitive_values of
+// terraform show -json, e.g. {"password": true, "tags": {"secret": true}}. List indexes
+// are keyed by their string value.
+func sensitiveValues(paths [][]rawStatePathStep) json.RawMessage {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	sensitive := map[string]interface{}{}
+	for _, path := range paths {
+		node := sensitive
+		for i, step := range path {
+			key := fmt.Sprintf("%v", step.Value)
+			if index, ok := step.Value.(map[string]interface{}); ok {
+				key = fmt.Sprintf("%v", index["value"])
+			}
+
+			if i == len(path)-1 {
+				node[key] = true
+				break
+			}
+			next, ok := node[key].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				node[key] = next
+			}
+			node = next
+		}
+	}
+
+	b, _ := json.Marshal(sensitive)
+	return b
+}
+
+// maskSensitive returns a copy of values with the sensitive values replaced, following the
+// structure of sensitive_values
+func maskSensitive(values interface{}, sensitive interface{}) interface{} {
+	switch s := sensitive.(type) {
+	case bool:
+		if s {
+			return "Sensitive Value"
+		}
+	case map[string]interface{}:
+		switch v := values.(type) {
+		case map[string]interface{}:
+			masked := make(map[string]interface{}, len(v))
+			for key, value := range v {
+				masked[key] = maskSensitive(value, s[key])
+			}
+			return masked
+		case []interface{}:
+			masked := make([]interface{}, len(v))
+			for i, value := range v {
+				masked[i] = maskSensitive(value, s[strconv.Itoa(i)])
+			}
+			return masked
+		}
+	case []interface{}:
+		if v, ok := values.([]interface{}); ok {
+			masked := make([]interface{}, len(v))
+			for i, value := range v {
+				var elem interface{}
+				if i < len(s) {
+					elem = s[i]
+				}
+				masked[i] = maskSensitive(value, elem)
+			}
+			return masked
+		}
+	}
+	return values
+}
+
+// ReadState parses either a .tfstate file or the output of terraform show -json
+func ReadState(stateJSON []byte) (*tfjson.State, error) {
+	probe := struct {
+		Version       int    `json:"version"`
+		FormatVersion string `json:"format_version"`
+	}{}
+
+	if err := json.Unmarshal(stateJSON, &probe); err != nil {
+		return nil, err
+	}
+
+	// terraform show -json
+	if probe.FormatVersion != "" {
+		state := &tfjson.State{}
+		if err := json.Unmarshal(stateJSON, state); err != nil {
+			return nil, err
+		}
+		return state, nil
+	}
+
+	if probe.Version != 4 {
+		return nil, errors.New(fmt.Sprintf("Unsupported state version %d, only version 4 state files are supported", probe.Version))
+	}
+
+	raw := rawState{}
+	if err := json.Unmarshal(stateJSON, &raw); err != nil {
+		return nil, err
+	}
+
+	return raw.stateValues(), nil
+}
+
+// stateValues converts a .tfstate file to the terraform show -json representation
+func (raw rawState) stateValues() *tfjson.State {
+	providerName := regexp.MustCompile(`^provider\["([^"]+)"\]`)
+
+	values := &tfjson.StateValues{
+		Outputs:    map[string]*tfjson.StateOutput{},
+		RootModule: &tfjson.StateModule{},
+	}
+
+	for name, o := range raw.Outputs {
+		values.Outputs[name] = &tfjson.StateOutput{
+			Value:     o.Value,
+			Sensitive: o.Sensitive,
+		}
+	}
+
+	modules := map[string]*tfjson.StateModule{"": values.RootModule}
+
+	for _, res := range raw.Resources {
+		module := stateModule(values.RootModule, modules, res.Module)
+
+		address := fmt.Sprintf("%s.%s", res.Type, res.Name)
+		if res.Mode == string(tfjson.DataResourceMode) {
+			address = fmt.Sprintf("data.%s", address)
+		}
+		if res.Module != "" {
+			address = fmt.Sprintf("%s.%s", res.Module, address)
+		}
+
+		provider := res.Provider
+		if m := providerName.FindStringSubmatch(res.Provider); m != nil {
+			provider = m[1]
+		}
+
+		for _, inst := range res.Instances {
+			instAddress := address
+			switch key := inst.IndexKey.(type) {
+			case string:
+				instAddress = fmt.Sprintf("%s[%q]", address, key)
+			case float64:
+				instAddress = fmt.Sprintf("%s[%d]", address, int(key))
+			}
+
+			attributes := inst.Attributes
+			if attributes == nil {
+				attributes = map[string]interface{}{}
+			}
+
+			module.Resources = append(module.Resources, &tfjson.StateResource{
+				Address:         instAddress,
+				Mode:            tfjson.ResourceMode(res.Mode),
+				Type:            res.Type,
+				Name:            res.Name,
+				Index:           inst.IndexKey,
+				ProviderName:    provider,
+				SchemaVersion:   inst.SchemaVersion,
+				AttributeValues: attributes,
+				DependsOn:       inst.Dependencies,
+				Tainted:         inst.Status == "tainted",
+				DeposedKey:      inst.Deposed,
+				SensitiveValues: sensitiveValues(inst.SensitiveAttributes),
+			})
+		}
+	}
+
+	return &tfjson.State{
+		FormatVersion:    "1.0",
+		TerraformVersion: raw.TerraformVersion,
+		Values:           values,
+	}
+}
+
+// stateModule returns the state module for address, creating it and its parents if needed
+func stateModule(root *tfjson.StateModule, modules map[string]*tfjson.StateModule, address string) *tfjson.StateModule {
+	if m, ok := modules[address]; ok {
+		return m
+	}
+
+	calls := splitModuleAddress(address)
+	parent := stateModule(root, modules, strings.Join(calls[:len(calls)-1], "."))
+
+	m := &tfjson.StateModule{Address: address}
+	parent.ChildModules = append(parent.ChildModules, m)
+	modules[address] = m
+
+	return m
+}
+
+// StateConfig reconstructs the configuration module tree from state, with
+// each resource's depends_on list recorded as its references
+func StateConfig(module *tfjson.StateModule) *tfjson.ConfigModule {
+	config := &tfjson.ConfigModule{}
+	if module == nil {
+		return config
+	}
+
+	modulePrefix := matchBrackets.ReplaceAllString(module.Address, "")
+	if modulePrefix != "" {
+		modulePrefix = fmt.Sprintf("%s.", modulePrefix)
+	}
+
+	resources := map[string]*tfjson.ConfigResource{}
+
+	for _, rst := range module.Resources {
+		address := strings.TrimPrefix(matchBrackets.ReplaceAllString(rst.Address, ""), modulePrefix)
+
+		if _, ok := resources[address]; !ok {
+			resources[address] = &tfjson.ConfigResource{
+				Address:           address,
+				Mode:              rst.Mode,
+				Type:              rst.Type,
+				Name:              rst.Name,
+				ProviderConfigKey: rst.ProviderName,
+				Expressions:       map[string]*tfjson.Expression{},
+			}
+			config.Resources = append(config.Resources, resources[address])
+		}
+
+		// Dependencies are absolute, references are relative to the module
+		for _, dep := range rst.DependsOn {
+			if !strings.HasPrefix(dep, modulePrefix) {
+				continue
+			}
+			dep = strings.TrimPrefix(dep, modulePrefix)
+
+			if resources[address].Expressions["depends_on"] == nil {
+				resources[address].Expressions["depends_on"] = &tfjson.Expression{
+					ExpressionData: &tfjson.ExpressionData{},
+				}
+			}
+			dependsOn := resources[address].Expressions["depends_on"]
+			if !isStringInSlice(dependsOn.References, dep) {
+				dependsOn.References = append(dependsOn.References, dep)
+				resources[address].DependsOn = append(resources[address].DependsOn, dep)
+			}
+		}
+	}
+
+	for _, childModule := range module.ChildModules {
+		calls := splitModuleAddress(childModule.Address)
+		name := matchBrackets.ReplaceAllString(strings.TrimPrefix(calls[len(calls)-1], "module."), "")
+
+		if config.ModuleCalls == nil {
+			config.ModuleCalls = map[string]*tfjson.ModuleCall{}
+		}
+
+		child := StateConfig(childModule)
+
+		// Instances of the same module call share configuration
+		if mc, ok := config.ModuleCalls[name]; ok {
+			mergeConfigModules(mc.Module, child)
+			continue
+		}
+
+		config.ModuleCalls[name] = &tfjson.ModuleCall{
+			Module: child,
+		}
+	}
+
+	return config
+}
+
+func mergeConfigModules(dst *tfjson.ConfigModule, src *tfjson.ConfigModule) {
+	for _, res := range src.Resources {
+		var existing *tfjson.ConfigResource
+		for _, dr := range dst.Resources {
+			if dr.Address == res.Address {
+				existing = dr
+				break
+			}
+		}
+
+		if existing == nil {
+			dst.Resources = append(dst.Resources, res)
+			continue
+		}
+
+		if dependsOn, ok := res.Expressions["depends_on"]; ok {
+			if existing.Expressions["depends_on"] == nil {
+				existing.Expressions["depends_on"] = &tfjson.Expression{
+					ExpressionData: &tfjson.ExpressionData{},
+				}
+			}
+			for _, dep := range dependsOn.References {
+				if !isStringInSlice(existing.Expressions["depends_on"].References, dep) {
+					existing.Expressions["depends_on"].References = append(existing.Expressions["depends_on"].References, dep)
+					existing.DependsOn = append(existing.DependsOn, dep)
+				}
+			}
+		}
+	}
+
+	for name, mc := range src.ModuleCalls {
+		if dst.ModuleCalls == nil {
+			dst.ModuleCalls = map[string]*tfjson.ModuleCall{}
+		}
+		if existing, ok := dst.ModuleCalls[name]; ok {
+			mergeConfigModules(existing.Module, mc.Module)
+		} else {
+			dst.ModuleCalls[name] = mc
+		}
+	}
+}
+
+func isStringInSlice(slice []string, s string) bool {
+	for _, el := range slice {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readStateFile reads and parses the state file at statePath
+func readStateFile(statePath string) (*tfjson.State, error) {
+	stateJSON, err := ioutil.ReadFile(statePath)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read State (%s): %s", statePath, err))
+	}
+
+	state, err := ReadState(stateJSON)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read State (%s): %s", statePath, err))
+	}
+
+	if state.Values == nil {
+		state.Values = &tfjson.StateValues{}
+	}
+	if state.Values.RootModule == nil {
+		state.Values.RootModule = &tfjson.StateModule{}
+	}
+
+	return state, nil
+}
+
+// getState reads the state file at r.StatePath
+func (r *rover) getState() error {
+	log.Println("Using provided state...")
+
+	state, err := readStateFile(r.StatePath)
+	if err != nil {
+		return err
+	}
+	r.State = state
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const testTFState = `{
+  "version": 4,
+  "terraform_version": "1.6.0",
+  "outputs": {"ip": {"value": "10.0.0.1", "type": "string"}, "secret": {"value": "x", "type": "string", "sensitive": true}},
+  "resources": [
+    {"mode": "managed", "type": "aws_vpc", "name": "main", "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+     "instances": [{"schema_version": 1, "attributes": {"id": "vpc-1"}}]},
+    {"mode": "data", "type": "aws_ami", "name": "ubuntu", "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+     "instances": [{"attributes": {"id": "ami-1"}}]},
+    {"module": "module.app[\"blue\"].module.web", "mode": "managed", "type": "aws_instance", "name": "web", "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+     "instances": [
+       {"index_key": 0, "status": "tainted", "attributes": {"id": "i-1"}, "dependencies": ["aws_vpc.main"]},
+       {"index_key": 1, "deposed": "abcd", "attributes": null}
+     ]},
+    {"mode": "managed", "type": "aws_subnet", "name": "private", "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+     "instances": [{"index_key": "a", "attributes": {"id": "subnet-a"}, "dependencies": ["aws_vpc.main"]}]}
+  ]
+}`
+
+const testShowJSON = `{
+  "format_version": "1.0",
+  "terraform_version": "1.6.0",
+  "values": {"root_module": {"resources": [
+    {"address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main", "provider_name": "registry.terraform.io/hashicorp/aws", "values": {"id": "vpc-1"}}
+  ]}}
+}`
+
+func TestReadState(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		wantErr   string
+		version   string
+		outputs   []string
+		addresses []string
+		modules   []string
+	}{
+		{
+			name:      "tfstate",
+			state:     testTFState,
+			version:   "1.6.0",
+			outputs:   []string{"ip", "secret"},
+			addresses: []string{"aws_subnet.private[\"a\"]", "aws_vpc.main", "data.aws_ami.ubuntu", "module.app[\"blue\"].module.web.aws_instance.web[0]", "module.app[\"blue\"].module.web.aws_instance.web[1]"},
+			modules:   []string{"module.app[\"blue\"]", "module.app[\"blue\"].module.web"},
+		},
+		{
+			name:      "show -json",
+			state:     testShowJSON,
+			version:   "1.6.0",
+			outputs:   []string{},
+			addresses: []string{"aws_vpc.main"},
+			modules:   []string{},
+		},
+		{
+			name:    "old state version",
+			state:   `{"version": 3, "modules": []}`,
+			wantErr: "Unsupported state version 3",
+		},
+		{
+			name:    "not JSON",
+			state:   `terraform.tfstate`,
+			wantErr: "invalid character",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := ReadState([]byte(tt.state))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadState() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadState() error = %v", err)
+			}
+
+			if state.TerraformVersion != tt.version {
+				t.Errorf("TerraformVersion = %q, want %q", state.TerraformVersion, tt.version)
+			}
+
+			outputs := []string{}
+			for name := range state.Values.Outputs {
+				outputs = append(outputs, name)
+			}
+			sort.Strings(outputs)
+			if !reflect.DeepEqual(outputs, tt.outputs) {
+				t.Errorf("outputs = %q, want %q", outputs, tt.outputs)
+			}
+
+			addresses := []string{}
+			modules := []string{}
+			var walk func(module *tfjson.StateModule)
+			walk = func(module *tfjson.StateModule) {
+				for _, rst := range module.Resources {
+					addresses = append(addresses, rst.Address)
+				}
+				for _, child := range module.ChildModules {
+					modules = append(modules, child.Address)
+					walk(child)
+				}
+			}
+			walk(state.Values.RootModule)
+			sort.Strings(addresses)
+			sort.Strings(modules)
+			if !reflect.DeepEqual(addresses, tt.addresses) {
+				t.Errorf("addresses = %q, want %q", addresses, tt.addresses)
+			}
+			if !reflect.DeepEqual(modules, tt.modules) {
+				t.Errorf("modules = %q, want %q", modules, tt.modules)
+			}
+		})
+	}
+}
+
+func TestReadStateInstances(t *testing.T) {
+	state, err := ReadState([]byte(testTFState))
+	if err != nil {
+		t.Fatalf("ReadState() error = %v", err)
+	}
+
+	resources := map[string]*tfjson.StateResource{}
+	var walk func(module *tfjson.StateModule)
+	walk = func(module *tfjson.StateModule) {
+		for _, rst := range module.Resources {
+			resources[rst.Address] = rst
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	walk(state.Values.RootModule)
+
+	vpc := resources["aws_vpc.main"]
+	if vpc.ProviderName != "registry.terraform.io/hashicorp/aws" || vpc.SchemaVersion != 1 {
+		t.Errorf("aws_vpc.main provider = %q, schema version = %d", vpc.ProviderName, vpc.SchemaVersion)
+	}
+	if data := resources["data.aws_ami.ubuntu"]; data.Mode != tfjson.DataResourceMode {
+		t.Errorf("data.aws_ami.ubuntu mode = %q, want data", data.Mode)
+	}
+
+	web0 := resources[`module.app["blue"].module.web.aws_instance.web[0]`]
+	if !web0.Tainted || !reflect.DeepEqual(web0.DependsOn, []string{"aws_vpc.main"}) {
+		t.Errorf("web[0] tainted = %v, depends on %q", web0.Tainted, web0.DependsOn)
+	}
+	web1 := resources[`module.app["blue"].module.web.aws_instance.web[1]`]
+	if web1.DeposedKey != "abcd" || web1.AttributeValues == nil {
+		t.Errorf("web[1] deposed = %q, attributes = %v", web1.DeposedKey, web1.AttributeValues)
+	}
+
+	if !state.Values.Outputs["secret"].Sensitive || state.Values.Outputs["ip"].Value != "10.0.0.1" {
+		t.Errorf("outputs = %+v", state.Values.Outputs)
+	}
+}
+
+func TestMaskSensitive(t *testing.T) {
+	state, err := ReadState([]byte(`{
+  "version": 4,
+  "terraform_version": "1.6.0",
+  "resources": [
+    {"mode": "managed", "type": "aws_db_instance", "name": "main", "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+     "instances": [{"attributes": {"id": "db-1", "password": "hunter2", "tags": {"env": "prod", "token": "abc"}, "users": ["admin", "root"]},
+       "sensitive_attributes": [
+         [{"type": "get_attr", "value": "password"}],
+         [{"type": "get_attr", "value": "tags"}, {"type": "index", "value": {"value": "token", "type": "string"}}],
+         [{"type": "get_attr", "value": "users"}, {"type": "index", "value": {"value": 1, "type": "number"}}]
+       ]},
+      {"deposed": "abcd", "attributes": {"id": "db-0", "password": "old"}}]}
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("ReadState() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"id":       "db-1",
+		"password": "Sensitive Value",
+		"tags":     map[string]interface{}{"env": "prod", "token": "Sensitive Value"},
+		"users":    []interface{}{"admin", "Sensitive Value"},
+	}
+
+	tests := []struct {
+		name          string
+		showSensitive bool
+		want          interface{}
+	}{
+		{"masked", false, want},
+		{"shown", true, state.Values.RootModule.Resources[0].AttributeValues},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &rover{State: state, ShowSensitive: tt.showSensitive}
+			rso := &ResourcesOverview{States: map[string]*StateOverview{}}
+			r.PopulateCurrentState(rso)
+
+			// The deposed object doesn't replace the current one
+			db := rso.States["aws_db_instance.main"]
+			if db == nil || !reflect.DeepEqual(db.Change.After, tt.want) {
+				t.Errorf("aws_db_instance.main = %+v, want %v", db, tt.want)
+			}
+		})
+	}
+
+	if password := state.Values.RootModule.Resources[0].AttributeValues["password"]; password != "hunter2" {
+		t.Errorf("state password = %v, want the state left unmasked", password)
+	}
+}
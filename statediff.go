@@ -0,0 +1,156 @@
+package main
+
+import (
+	"log"
+	"reflect"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// stateResources flattens a state module tree into resource instances keyed by address
+func stateResources(module *tfjson.StateModule, resources map[string]*tfjson.StateResource, modules map[string]string) {
+	if module == nil {
+		return
+	}
+
+	for _, rst := range module.Resources {
+		// Deposed objects are leftovers of a failed create_before_destroy, not part of the diff
+		if rst.DeposedKey != "" {
+			continue
+		}
+		resources[rst.Address] = rst
+		modules[rst.Address] = module.Address
+	}
+
+	for _, childModule := range module.ChildModules {
+		stateResources(childModule, resources, modules)
+	}
+}
+
+// DiffStates compares two states and describes what changed between them as a plan,
+// so the difference can be visualized like any other plan. Data sources are read again
+// with every refresh, so they are reported as reads rather than changes.
+func DiffStates(prior *tfjson.State, current *tfjson.State) *tfjson.Plan {
+	priorResources := map[string]*tfjson.StateResource{}
+	currentResources := map[string]*tfjson.StateResource{}
+	modules := map[string]string{}
+
+	stateResources(prior.Values.RootModule, priorResources, modules)
+	stateResources(current.Values.RootModule, currentResources, modules)
+
+	addresses := []string{}
+	for address := range modules {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	plan := &tfjson.Plan{
+		FormatVersion:    current.FormatVersion,
+		TerraformVersion: current.TerraformVersion,
+		PriorState:       prior,
+		PlannedValues:    current.Values,
+		OutputChanges:    map[string]*tfjson.Change{},
+	}
+
+	for _, address := range addresses {
+		before, existed := priorResources[address]
+		after, exists := currentResources[address]
+
+		rst := after
+		change := &tfjson.Change{}
+
+		switch {
+		case (existed && before.Mode == tfjson.DataResourceMode) || (exists && after.Mode == tfjson.DataResourceMode):
+			// Data sources no longer read aren't part of the current infrastructure
+			if !exists {
+				continue
+			}
+			change.Actions = tfjson.Actions{tfjson.ActionRead}
+			if existed {
+				change.Before = before.AttributeValues
+			}
+			change.After = after.AttributeValues
+		case existed && !exists:
+			rst = before
+			change.Actions = tfjson.Actions{tfjson.ActionDelete}
+			change.Before = before.AttributeValues
+		case !existed && exists:
+			change.Actions = tfjson.Actions{tfjson.ActionCreate}
+			change.After = after.AttributeValues
+		default:
+			change.Actions = tfjson.Actions{tfjson.ActionNoop}
+			if !reflect.DeepEqual(before.AttributeValues, after.AttributeValues) {
+				change.Actions = tfjson.Actions{tfjson.ActionUpdate}
+			}
+			change.Before = before.AttributeValues
+			change.After = after.AttributeValues
+		}
+
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address:       address,
+			ModuleAddress: modules[address],
+			Mode:          rst.Mode,
+			Type:          rst.Type,
+			Name:          rst.Name,
+			Index:         rst.Index,
+			ProviderName:  rst.ProviderName,
+			Change:        change,
+		})
+	}
+
+	// Outputs
+	for name, o := range prior.Values.Outputs {
+		plan.OutputChanges[name] = &tfjson.Change{
+			Actions:         tfjson.Actions{tfjson.ActionDelete},
+			Before:          o.Value,
+			BeforeSensitive: o.Sensitive,
+			AfterSensitive:  false,
+		}
+	}
+
+	for name, o := range current.Values.Outputs {
+		oc, existed := plan.OutputChanges[name]
+		if !existed {
+			oc = &tfjson.Change{
+				Actions:         tfjson.Actions{tfjson.ActionCreate},
+				BeforeSensitive: false,
+			}
+			plan.OutputChanges[name] = oc
+		} else if reflect.DeepEqual(oc.Before, o.Value) {
+			oc.Actions = tfjson.Actions{tfjson.ActionNoop}
+		} else {
+			oc.Actions = tfjson.Actions{tfjson.ActionUpdate}
+		}
+		oc.After = o.Value
+		oc.AfterSensitive = o.Sensitive
+	}
+
+	// Configuration is reconstructed from both states
+	rootModule := StateConfig(prior.Values.RootModule)
+	mergeConfigModules(rootModule, StateConfig(current.Values.RootModule))
+	plan.Config = &tfjson.Config{
+		RootModule: rootModule,
+	}
+
+	return plan
+}
+
+// getStateDiff compares the state files at r.PriorStatePath and r.StatePath
+func (r *rover) getStateDiff() error {
+	log.Println("Comparing provided states...")
+
+	prior, err := readStateFile(r.PriorStatePath)
+	if err != nil {
+		return err
+	}
+
+	current, err := readStateFile(r.StatePath)
+	if err != nil {
+		return err
+	}
+
+	r.Plan = DiffStates(prior, current)
+
+	return nil
+}
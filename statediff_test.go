@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func testState(outputs map[string]interface{}, resources ...*tfjson.StateResource) *tfjson.State {
+	values := &tfjson.StateValues{
+		Outputs:    map[string]*tfjson.StateOutput{},
+		RootModule: &tfjson.StateModule{},
+	}
+	for name, value := range outputs {
+		values.Outputs[name] = &tfjson.StateOutput{Value: value}
+	}
+
+	modules := map[string]*tfjson.StateModule{"": values.RootModule}
+	for _, rst := range resources {
+		module := ""
+		if i := strings.LastIndex(rst.Address, fmt.Sprintf(".%s.%s", rst.Type, rst.Name)); i > 0 {
+			module = strings.TrimSuffix(strings.TrimSuffix(rst.Address[:i], "data"), ".")
+		}
+		m := stateModule(values.RootModule, modules, module)
+		m.Resources = append(m.Resources, rst)
+	}
+
+	return &tfjson.State{
+		FormatVersion:    "1.0",
+		TerraformVersion: "1.6.0",
+		Values:           values,
+	}
+}
+
+func testStateResource(address string, resourceType string, name string, values map[string]interface{}) *tfjson.StateResource {
+	mode := tfjson.ManagedResourceMode
+	if strings.HasPrefix(address, "data.") {
+		mode = tfjson.DataResourceMode
+	}
+	return &tfjson.StateResource{
+		Address:         address,
+		Mode:            mode,
+		Type:            resourceType,
+		Name:            name,
+		AttributeValues: values,
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	deposed := testStateResource("aws_instance.old", "aws_instance", "old", map[string]interface{}{"id": "i-0"})
+	deposed.DeposedKey = "abcd"
+
+	prior := testState(
+		map[string]interface{}{"ip": "10.0.0.1", "gone": "x", "same": "y"},
+		testStateResource("aws_vpc.main", "aws_vpc", "main", map[string]interface{}{"id": "vpc-1"}),
+		testStateResource("aws_subnet.a", "aws_subnet", "a", map[string]interface{}{"cidr": "10.0.1.0/24"}),
+		testStateResource("module.db.aws_db_instance.main", "aws_db_instance", "main", map[string]interface{}{"id": "db-1"}),
+		deposed,
+		testStateResource("data.aws_ami.ubuntu", "aws_ami", "ubuntu", map[string]interface{}{"id": "ami-1"}),
+		testStateResource("data.aws_region.old", "aws_region", "old", map[string]interface{}{"name": "eu-west-1"}),
+	)
+	current := testState(
+		map[string]interface{}{"ip": "10.0.0.2", "new": "z", "same": "y"},
+		testStateResource("aws_vpc.main", "aws_vpc", "main", map[string]interface{}{"id": "vpc-1"}),
+		testStateResource("aws_subnet.a", "aws_subnet", "a", map[string]interface{}{"cidr": "10.0.2.0/24"}),
+		testStateResource("module.app.aws_instance.web[0]", "aws_instance", "web", map[string]interface{}{"id": "i-1"}),
+		testStateResource("data.aws_ami.ubuntu", "aws_ami", "ubuntu", map[string]interface{}{"id": "ami-2"}),
+		testStateResource("data.aws_caller_identity.current", "aws_caller_identity", "current", map[string]interface{}{"id": "123"}),
+	)
+
+	plan := DiffStates(prior, current)
+
+	wantChanges := []struct {
+		address string
+		module  string
+		actions tfjson.Actions
+	}{
+		{"aws_subnet.a", "", tfjson.Actions{tfjson.ActionUpdate}},
+		{"aws_vpc.main", "", tfjson.Actions{tfjson.ActionNoop}},
+		// Data sources are read, whether new or not, and those no longer read are left out
+		{"data.aws_ami.ubuntu", "", tfjson.Actions{tfjson.ActionRead}},
+		{"data.aws_caller_identity.current", "", tfjson.Actions{tfjson.ActionRead}},
+		{"module.app.aws_instance.web[0]", "module.app", tfjson.Actions{tfjson.ActionCreate}},
+		{"module.db.aws_db_instance.main", "module.db", tfjson.Actions{tfjson.ActionDelete}},
+	}
+
+	if len(plan.ResourceChanges) != len(wantChanges) {
+		t.Fatalf("resource changes = %d, want %d", len(plan.ResourceChanges), len(wantChanges))
+	}
+	for i, want := range wantChanges {
+		rc := plan.ResourceChanges[i]
+		if rc.Address != want.address || rc.ModuleAddress != want.module || !reflect.DeepEqual(rc.Change.Actions, want.actions) {
+			t.Errorf("change %d = %s (%q) %v, want %s (%q) %v", i, rc.Address, rc.ModuleAddress, rc.Change.Actions, want.address, want.module, want.actions)
+		}
+	}
+
+	deleted := plan.ResourceChanges[5]
+	if deleted.Change.Before == nil || deleted.Change.After != nil || deleted.Type != "aws_db_instance" {
+		t.Errorf("deleted change = %+v, want prior values only", deleted.Change)
+	}
+
+	wantOutputs := map[string]tfjson.Actions{
+		"ip":   {tfjson.ActionUpdate},
+		"gone": {tfjson.ActionDelete},
+		"new":  {tfjson.ActionCreate},
+		"same": {tfjson.ActionNoop},
+	}
+	for name, actions := range wantOutputs {
+		oc, ok := plan.OutputChanges[name]
+		if !ok {
+			t.Errorf("output %s missing", name)
+			continue
+		}
+		if !reflect.DeepEqual(oc.Actions, actions) {
+			t.Errorf("output %s actions = %v, want %v", name, oc.Actions, actions)
+		}
+	}
+
+	calls := plan.Config.RootModule.ModuleCalls
+	if _, ok := calls["app"]; !ok {
+		t.Error("module call app missing from configuration")
+	}
+	if _, ok := calls["db"]; !ok {
+		t.Error("module call db missing from configuration")
+	}
+	if plan.TerraformVersion != "1.6.0" || plan.PriorState != prior {
+		t.Errorf("plan version = %q, prior state = %p", plan.TerraformVersion, plan.PriorState)
+	}
+}
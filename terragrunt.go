@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const TerragruntConfigFile = "terragrunt.hcl"
+
+// TerragruntRootConfigFile is the shared root configuration of recent Terragrunt stacks
+const TerragruntRootConfigFile = "root.hcl"
+
+var terragruntSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "dependency", LabelNames: []string{"name"}},
+		{Type: "dependencies"},
+	},
+}
+
+var terragruntDependencySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "config_path"},
+	},
+}
+
+var terragruntDependenciesSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "paths"},
+	},
+}
+
+// FindTerragruntUnits returns every directory below dir containing a terragrunt.hcl file.
+// If dir has child units, its own terragrunt.hcl is the shared root configuration and not a unit.
+func FindTerragruntUnits(dir string) ([]string, error) {
+	units := []string{}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			name := info.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Name() == TerragruntConfigFile {
+			units = append(units, filepath.Dir(path))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(units) > 1 {
+		for i, unit := range units {
+			if filepath.Clean(unit) == filepath.Clean(dir) {
+				units = append(units[:i], units[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return units, nil
+}
+
+// findInParentFolders returns the path of the first file with the name in the parent
+// directories of dir, like Terragrunt's find_in_parent_folders
+func findInParentFolders(dir string, name string) (string, bool) {
+	for parent := filepath.Dir(dir); ; parent = filepath.Dir(parent) {
+		path := filepath.Join(parent, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+		if parent == filepath.Dir(parent) {
+			return "", false
+		}
+	}
+}
+
+// terragruntEvalContext stubs the Terragrunt functions commonly used in dependency paths,
+// e.g. "${get_parent_terragrunt_dir()}/vpc", for the unit in unitDir
+func terragruntEvalContext(unitDir string) *hcl.EvalContext {
+	dirFunc := func(dir string) function.Function {
+		return function.New(&function.Spec{
+			Type: function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				return cty.StringVal(dir), nil
+			},
+		})
+	}
+
+	findInParent := function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := TerragruntConfigFile
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+			if path, ok := findInParentFolders(unitDir, name); ok {
+				return cty.StringVal(path), nil
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.NilVal, errors.New(fmt.Sprintf("%s not found in the parent folders of %s", name, unitDir))
+		},
+	})
+
+	// The directory of the root configuration the unit includes
+	parentDir := unitDir
+	if path, ok := findInParentFolders(unitDir, TerragruntConfigFile); ok {
+		parentDir = filepath.Dir(path)
+	} else if path, ok := findInParentFolders(unitDir, TerragruntRootConfigFile); ok {
+		parentDir = filepath.Dir(path)
+	}
+
+	return &hcl.EvalContext{
+		Functions: map[string]function.Function{
+			"get_terragrunt_dir":          dirFunc(unitDir),
+			"get_original_terragrunt_dir": dirFunc(unitDir),
+			"get_parent_terragrunt_dir":   dirFunc(parentDir),
+			"find_in_parent_folders":      findInParent,
+			"dirname": function.New(&function.Spec{
+				Params: []function.Parameter{{Name: "path", Type: cty.String}},
+				Type:   function.StaticReturnType(cty.String),
+				Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+					return cty.StringVal(filepath.Dir(args[0].AsString())), nil
+				},
+			}),
+		},
+	}
+}
+
+// TerragruntDependencies returns the directories of the units a unit depends on,
+// from its dependency and dependencies blocks
+func TerragruntDependencies(unitDir string) ([]string, error) {
+	parser := hclparse.NewParser()
+
+	file, diags := parser.ParseHCLFile(filepath.Join(unitDir, TerragruntConfigFile))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	content, _, diags := file.Body.PartialContent(terragruntSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	ctx := terragruntEvalContext(unitDir)
+	paths := []string{}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "dependency":
+			attrs, _, _ := block.Body.PartialContent(terragruntDependencySchema)
+			attr, ok := attrs.Attributes["config_path"]
+			if !ok {
+				continue
+			}
+
+			val, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() || !val.IsKnown() || val.IsNull() {
+				log.Printf("Unable to resolve config_path of dependency %s in %s, skipping...\n", block.Labels[0], unitDir)
+				continue
+			}
+			paths = append(paths, val.AsString())
+		case "dependencies":
+			attrs, _, _ := block.Body.PartialContent(terragruntDependenciesSchema)
+			attr, ok := attrs.Attributes["paths"]
+			if !ok {
+				continue
+			}
+
+			val, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() || !val.IsKnown() || val.IsNull() || !val.CanIterateElements() {
+				log.Printf("Unable to resolve dependencies paths in %s, skipping...\n", unitDir)
+				continue
+			}
+			for it := val.ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				if v.IsKnown() && !v.IsNull() {
+					paths = append(paths, v.AsString())
+				}
+			}
+		}
+	}
+
+	deps := []string{}
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(unitDir, p)
+		}
+		deps = append(deps, filepath.Clean(p))
+	}
+
+	return deps, nil
+}
+
+// isTerragrunt checks if the working directory is a Terragrunt unit, or a stack of units
+// without Terraform configuration of its own
+func (r *rover) isTerragrunt() bool {
+	if _, err := os.Stat(filepath.Join(r.WorkingDir, TerragruntConfigFile)); err == nil {
+		return true
+	}
+
+	tfFiles, _ := filepath.Glob(filepath.Join(r.WorkingDir, "*.tf"))
+	if len(tfFiles) > 0 {
+		return false
+	}
+
+	units, err := FindTerragruntUnits(r.WorkingDir)
+	return err == nil && len(units) > 0
+}
+
+// planTerragruntUnit runs terragrunt plan in unitDir and returns the plan
+func (r *rover) planTerragruntUnit(unitDir string, tmpDir string) (*tfjson.Plan, error) {
+	planPath := filepath.Join(tmpDir, fmt.Sprintf("%s.tfplan", stackUnitKey(unitDir)))
+
+	args := append(r.planArgs(planPath), "--terragrunt-non-interactive")
+	if r.PlanMode == "" {
+		r.PlanMode = PlanModeNormal
+	}
+
+	cmd := exec.Command(r.TerragruntPath, args...)
+	cmd.Dir = unitDir
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to run Plan: %s", err))
+	}
+
+	var stdout bytes.Buffer
+	cmd = exec.Command(r.TerragruntPath, "show", "-json", planPath, "--terragrunt-non-interactive")
+	cmd.Dir = unitDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read Plan: %s", err))
+	}
+
+	plan, err := r.readPlanJSON(stdout.Bytes())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read Plan: %s", err))
+	}
+
+	return plan, nil
+}
+
+// getTerragruntPlan plans every Terragrunt unit in the working directory, or reads their
+// plan JSON files, and combines them into a single plan
+func (r *rover) getTerragruntPlan(tmpDir string) error {
+	unitDirs, err := FindTerragruntUnits(r.WorkingDir)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to find Terragrunt units: %s", err))
+	}
+
+	root, err := filepath.Abs(r.WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	rels := map[string]string{}
+	for _, unitDir := range unitDirs {
+		unitDir, _ = filepath.Abs(unitDir)
+		rel, _ := filepath.Rel(root, unitDir)
+		if rel == "." {
+			rel = filepath.Base(unitDir)
+		}
+		rels[rel] = unitDir
+	}
+
+	paths := []string{}
+	for rel := range rels {
+		paths = append(paths, rel)
+	}
+	keys := map[string]string{}
+	for rel, key := range stackUnitKeys(paths) {
+		keys[rels[rel]] = key
+	}
+
+	units := []*StackUnit{}
+	r.Units = map[string]string{}
+
+	for unitDir, key := range keys {
+		rel, _ := filepath.Rel(root, unitDir)
+		unit := &StackUnit{
+			Key:    key,
+			Dir:    unitDir,
+			Source: fmt.Sprintf("./%s", filepath.ToSlash(rel)),
+		}
+
+		deps, err := TerragruntDependencies(unitDir)
+		if err != nil {
+			log.Printf("Unable to read dependencies of %s: %s\n", unitDir, err)
+		}
+		for _, dep := range deps {
+			if depKey, ok := keys[dep]; ok {
+				unit.DependsOn = append(unit.DependsOn, depKey)
+			} else {
+				log.Printf("Dependency %s of %s is outside of the working directory, skipping...\n", dep, unitDir)
+			}
+		}
+
+		if r.UnitPlanJSON != "" {
+			log.Printf("Using provided JSON plan for Terragrunt unit %s...", key)
+
+			planJson, err := ioutil.ReadFile(filepath.Join(unitDir, r.UnitPlanJSON))
+			if err != nil {
+				return errors.New(fmt.Sprintf("Unable to read Plan (%s): %s", unitDir, err))
+			}
+
+			unit.Plan, err = r.readPlanJSON(planJson)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Unable to read Plan (%s): %s", unitDir, err))
+			}
+			unit.Checks = r.Checks
+			unit.Deferred = r.Deferred
+		} else {
+			log.Printf("Generating plan for Terragrunt unit %s...", key)
+
+			unit.Plan, err = r.planTerragruntUnit(unitDir, tmpDir)
+			if err != nil {
+				return errors.New(fmt.Sprintf("%s (%s)", err, unitDir))
+			}
+			unit.Checks = r.Checks
+			unit.Deferred = r.Deferred
+		}
+
+		units = append(units, unit)
+		r.Units[key] = unitDir
+	}
+
+	r.combineStackUnits(units)
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+var variablesSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "variable", LabelNames: []string{"name"}},
+	},
+}
+
+var variableSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "validation"},
+	},
+}
+
+// VariableValidations returns the validation conditions of the variables declared in dir,
+// as written in the configuration. tfconfig and the plan don't include them.
+func VariableValidations(dir string) map[string][]Condition {
+	validations := map[string][]Condition{}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.tf"))
+	parser := hclparse.NewParser()
+
+	for _, fname := range files {
+		src, err := ioutil.ReadFile(fname)
+		if err != nil {
+			continue
+		}
+
+		file, diags := parser.ParseHCL(src, fname)
+		if diags.HasErrors() {
+			continue
+		}
+
+		content, _, _ := file.Body.PartialContent(variablesSchema)
+		for _, block := range content.Blocks {
+			variable, _, _ := block.Body.PartialContent(variableSchema)
+
+			for _, validationBlock := range variable.Blocks {
+				validation, _, ok := parseCondition(validationBlock, src)
+				if !ok {
+					continue
+				}
+
+				validations[block.Labels[0]] = append(validations[block.Labels[0]], validation)
+			}
+		}
+	}
+
+	return validations
+}
+
+// variableValue returns the value a variable had when planning: the input value of a root
+// module variable, or the constant value passed by the module call
+func (r *rover) variableValue(parentConfig string, name string, sensitive bool) interface{} {
+	var value interface{}
+
+	if parentConfig == "" {
+		if r.Plan == nil || r.Plan.Variables[name] == nil {
+			return nil
+		}
+		value = r.Plan.Variables[name].Value
+	} else {
+		configs := r.RSO.Configs
+		if configs[parentConfig] == nil || configs[parentConfig].ModuleConfig == nil {
+			return nil
+		}
+
+		expression, ok := configs[parentConfig].ModuleConfig.Expressions[name]
+		if !ok || expression.ExpressionData == nil || expression.ConstantValue == nil {
+			return nil
+		}
+		value = expression.ConstantValue
+	}
+
+	if sensitive && !r.ShowSensitive {
+		return "Sensitive Value"
+	}
+
+	return value
+}
+
+// variableType returns the type of a variable from the plan's configuration, which has no
+// declared types, e.g. "list(string)" for a default of ["a", "b"]. Falls back to the planned value.
+func (r *rover) variableType(parentConfig string, name string, v *tfjson.ConfigVariable) string {
+	if v != nil && v.Default != nil {
+		return valueType(v.Default)
+	}
+	return valueType(r.variableValue(parentConfig, name, false))
+}
+
+// valueType returns the Terraform type of a JSON value, or "" for null
+func valueType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		if elem, ok := elementType(v); ok {
+			return fmt.Sprintf("list(%s)", elem)
+		}
+		return "tuple"
+	case map[string]interface{}:
+		values := []interface{}{}
+		for _, e := range v {
+			values = append(values, e)
+		}
+		if elem, ok := elementType(values); ok {
+			return fmt.Sprintf("map(%s)", elem)
+		}
+		return "object"
+	}
+	return ""
+}
+
+// elementType returns the type shared by all values, "any" if there are none
+func elementType(values []interface{}) (string, bool) {
+	elem := "any"
+	for i, e := range values {
+		t := valueType(e)
+		if t == "" || (i > 0 && t != elem) {
+			return "", false
+		}
+		elem = t
+	}
+	return elem, true
+}
+
+// variableConfigSensitive returns whether the variable with configuration ID vid is sensitive
+func (r *rover) variableConfigSensitive(vid string) bool {
+	config := r.RSO.Configs[vid]
+	return config != nil && config.VariableConfig != nil && config.VariableConfig.Sensitive
+}
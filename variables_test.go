@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestVariableType(t *testing.T) {
+	r := &rover{
+		Plan: &tfjson.Plan{
+			Variables: map[string]*tfjson.PlanVariable{
+				"region":   {Value: "eu-west-1"},
+				"password": {Value: "hunter2"},
+			},
+		},
+		RSO: &ResourcesOverview{
+			Configs: map[string]*ConfigOverview{
+				"module.app": {
+					ModuleConfig: &tfjson.ModuleCall{
+						Expressions: map[string]*tfjson.Expression{
+							"replicas": {ExpressionData: &tfjson.ExpressionData{ConstantValue: float64(3)}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		parentConfig string
+		variable     string
+		config       *tfjson.ConfigVariable
+		want         string
+	}{
+		{"string default", "", "name", &tfjson.ConfigVariable{Default: "web"}, "string"},
+		{"number default", "", "count", &tfjson.ConfigVariable{Default: float64(2)}, "number"},
+		{"bool default", "", "enabled", &tfjson.ConfigVariable{Default: true}, "bool"},
+		{"list default", "", "zones", &tfjson.ConfigVariable{Default: []interface{}{"a", "b"}}, "list(string)"},
+		{"empty list default", "", "ids", &tfjson.ConfigVariable{Default: []interface{}{}}, "list(any)"},
+		{"mixed list default", "", "mixed", &tfjson.ConfigVariable{Default: []interface{}{"a", float64(1)}}, "tuple"},
+		{"map default", "", "tags", &tfjson.ConfigVariable{Default: map[string]interface{}{"env": "prod", "team": "ops"}}, "map(string)"},
+		{"nested map default", "", "sizes", &tfjson.ConfigVariable{Default: map[string]interface{}{"web": []interface{}{float64(1)}}}, "map(list(number))"},
+		{"object default", "", "settings", &tfjson.ConfigVariable{Default: map[string]interface{}{"name": "web", "size": float64(1)}}, "object"},
+		{"root value without default", "", "region", &tfjson.ConfigVariable{}, "string"},
+		{"sensitive root value", "", "password", &tfjson.ConfigVariable{Sensitive: true}, "string"},
+		{"module input without default", "module.app", "replicas", &tfjson.ConfigVariable{}, "number"},
+		{"unknown", "", "missing", &tfjson.ConfigVariable{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.variableType(tt.parentConfig, tt.variable, tt.config); got != tt.want {
+				t.Errorf("variableType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVariableValidations(t *testing.T) {
+	dir := t.TempDir()
+	config := `variable "env" {
+  type = string
+
+  validation {
+    condition     = contains(["dev", "prod"], var.env)
+    error_message = "Must be dev or prod."
+  }
+
+  validation {
+    condition     = length(var.env) > 0
+    error_message = "Must not be ${"empty"}."
+  }
+}
+
+variable "name" {}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "variables.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.tf"), []byte(`variable "x" {`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]Condition{
+		"env": {
+			{Condition: `contains(["dev", "prod"], var.env)`, ErrorMessage: "Must be dev or prod."},
+			{Condition: "length(var.env) > 0", ErrorMessage: "Must not be empty."},
+		},
+	}
+	if got := VariableValidations(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("VariableValidations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestVariableValue(t *testing.T) {
+	r := &rover{
+		Plan: &tfjson.Plan{
+			Variables: map[string]*tfjson.PlanVariable{"password": {Value: "hunter2"}},
+		},
+		RSO: &ResourcesOverview{
+			Configs: map[string]*ConfigOverview{
+				"module.app": {
+					ModuleConfig: &tfjson.ModuleCall{
+						Expressions: map[string]*tfjson.Expression{
+							"replicas": {ExpressionData: &tfjson.ExpressionData{ConstantValue: float64(3)}},
+							"subnet":   {ExpressionData: &tfjson.ExpressionData{References: []string{"aws_subnet.a.id", "aws_subnet.a"}}},
+						},
+					},
+				},
+				"var.password":            {VariableConfig: &tfjson.ConfigVariable{Sensitive: true}},
+				"module.app.var.replicas": {VariableConfig: &tfjson.ConfigVariable{}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		parentConfig  string
+		variable      string
+		sensitive     bool
+		showSensitive bool
+		want          interface{}
+	}{
+		{"root input", "", "password", false, false, "hunter2"},
+		{"sensitive root input", "", "password", true, false, "Sensitive Value"},
+		{"shown sensitive root input", "", "password", true, true, "hunter2"},
+		{"missing root input", "", "region", false, false, nil},
+		{"constant module input", "module.app", "replicas", false, false, float64(3)},
+		{"module input with references", "module.app", "subnet", false, false, nil},
+		{"unknown module", "module.db", "replicas", false, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.ShowSensitive = tt.showSensitive
+			if got := r.variableValue(tt.parentConfig, tt.variable, tt.sensitive); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("variableValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !r.variableConfigSensitive("var.password") {
+		t.Error("expected var.password to be sensitive")
+	}
+	if r.variableConfigSensitive("module.app.var.replicas") || r.variableConfigSensitive("var.missing") {
+		t.Error("expected only var.password to be sensitive")
+	}
+}
@@ -61,6 +61,9 @@ func (r *rover) generateZip(fe fs.FS, filename string) error {
 	if err = AddFileToZip(zipWriter, "graph", r.Graph); err != nil {
 		return err
 	}
+	if err = AddFileToZip(zipWriter, "modules", r.Modules); err != nil {
+		return err
+	}
 
 	return nil
 }